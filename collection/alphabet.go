@@ -18,10 +18,11 @@ func MakeAlphabet(characters string, wildcards ...rune) Alphabet {
 	return alphabet
 }
 
+// String returns the characters in the order of their numbers.
 func (alphabet Alphabet) String() string {
 	charString := ""
-	for _, char := range alphabet.char {
-		charString += string(char)
+	for num := range alphabet.Len() {
+		charString += string(alphabet.char[num])
 	}
 	return charString
 }
@@ -0,0 +1,115 @@
+package collection
+
+import "math/bits"
+
+// Set is a set of alphabet numbers.
+type Set []uint64
+
+// MakeSet makes a set containing the given numbers.
+func MakeSet(nums ...int) Set {
+	var set Set
+	for _, num := range nums {
+		set = set.Insert(num)
+	}
+	return set
+}
+
+// MakeSetRange makes a set containing all numbers from 0 to size-1.
+func MakeSetRange(size int) Set {
+	var set Set
+	for num := range size {
+		set = set.Insert(num)
+	}
+	return set
+}
+
+// Insert returns the set with num added. Like append, it may reuse the storage of the set.
+func (set Set) Insert(num int) Set {
+	word := num / 64
+	for len(set) <= word {
+		set = append(set, 0)
+	}
+	set[word] |= 1 << (num % 64)
+	return set
+}
+
+func (set Set) Contains(num int) bool {
+	word := num / 64
+	if num < 0 || word >= len(set) {
+		return false
+	}
+	return set[word]&(1<<(num%64)) != 0
+}
+
+func (set Set) Len() int {
+	count := 0
+	for _, word := range set {
+		count += bits.OnesCount64(word)
+	}
+	return count
+}
+
+// Single returns the only number of a set. Fails if the set does not contain exactly one number.
+func (set Set) Single() (int, bool) {
+	if set.Len() != 1 {
+		return -1, false
+	}
+	return set.Numbers()[0], true
+}
+
+// Numbers returns the numbers of the set in increasing order.
+func (set Set) Numbers() []int {
+	var nums []int
+	for i, word := range set {
+		for word != 0 {
+			bit := bits.TrailingZeros64(word)
+			nums = append(nums, i*64+bit)
+			word &^= 1 << bit
+		}
+	}
+	return nums
+}
+
+func (set Set) Copy() Set {
+	return append(Set(nil), set...)
+}
+
+// Intersect returns a set with all numbers contained in set and in other.
+func (set Set) Intersect(other Set) Set {
+	ret := make(Set, min(len(set), len(other)))
+	for i := range ret {
+		ret[i] = set[i] & other[i]
+	}
+	return ret
+}
+
+// Union returns a set with all numbers contained in set or in other.
+func (set Set) Union(other Set) Set {
+	ret := make(Set, max(len(set), len(other)))
+	for i := range ret {
+		if i < len(set) {
+			ret[i] |= set[i]
+		}
+		if i < len(other) {
+			ret[i] |= other[i]
+		}
+	}
+	return ret
+}
+
+// Equal checks, whether both sets contain the same numbers.
+func (set Set) Equal(other Set) bool {
+	for i := range max(len(set), len(other)) {
+		var word, otherWord uint64
+		if i < len(set) {
+			word = set[i]
+		}
+		if i < len(other) {
+			otherWord = other[i]
+		}
+		if word != otherWord {
+			return false
+		}
+	}
+	return true
+}
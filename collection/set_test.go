@@ -0,0 +1,64 @@
+package collection
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSet_Insert(t *testing.T) {
+	set := MakeSet(3, 70, 3)
+	if set.Len() != 2 {
+		t.Errorf("Set length is incorrect. Expected %d, got %d.", 2, set.Len())
+	}
+	if !set.Contains(70) || !set.Contains(3) {
+		t.Errorf("Set does not contain inserted numbers: %v", set.Numbers())
+	}
+	if set.Contains(4) || set.Contains(200) || set.Contains(-1) {
+		t.Errorf("Set contains numbers which were never inserted: %v", set.Numbers())
+	}
+}
+
+func TestSet_Numbers(t *testing.T) {
+	actual := MakeSet(65, 1, 0).Numbers()
+	expected := []int{0, 1, 65}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("Numbers is incorrect. Expected %v, got %v.", expected, actual)
+	}
+	if MakeSetRange(100).Len() != 100 {
+		t.Errorf("MakeSetRange is incorrect. Expected %d numbers, got %d.", 100, MakeSetRange(100).Len())
+	}
+}
+
+func TestSet_Single(t *testing.T) {
+	num, ok := MakeSet(66).Single()
+	if !ok || num != 66 {
+		t.Errorf("Single is incorrect. Expected %d, got %d.", 66, num)
+	}
+	_, ok = MakeSet(1, 2).Single()
+	if ok {
+		t.Errorf("Single accepts a set with two numbers.")
+	}
+	_, ok = MakeSet().Single()
+	if ok {
+		t.Errorf("Single accepts the empty set.")
+	}
+}
+
+func TestSet_Operations(t *testing.T) {
+	set1 := MakeSet(1, 2, 100)
+	set2 := MakeSet(2, 3)
+	if !set1.Intersect(set2).Equal(MakeSet(2)) {
+		t.Errorf("Intersect is incorrect. Got %v.", set1.Intersect(set2).Numbers())
+	}
+	if !set1.Union(set2).Equal(MakeSet(1, 2, 3, 100)) {
+		t.Errorf("Union is incorrect. Got %v.", set1.Union(set2).Numbers())
+	}
+	if !MakeSet(1).Equal(MakeSet(1, 100).Intersect(MakeSet(1))) {
+		t.Errorf("Equal is incorrect on sets of different storage size.")
+	}
+	copied := set1.Copy()
+	copied = copied.Insert(5)
+	if set1.Contains(5) {
+		t.Errorf("Copy shares storage with the original set.")
+	}
+}
@@ -0,0 +1,239 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+	"slices"
+)
+
+// maxEliminationStates bounds the automata that are converted back into rule trees.
+const maxEliminationStates = 64
+
+// Intersect returns an automaton accepting the strings accepted by both automata.
+// Fails if the automata have different alphabets.
+func (a Automaton) Intersect(other Automaton) (Automaton, bool) {
+	return a.product(other, func(accepted bool, otherAccepted bool) bool {
+		return accepted && otherAccepted
+	})
+}
+
+// Difference returns an automaton accepting the strings accepted by a but not by other.
+// Fails if the automata have different alphabets.
+func (a Automaton) Difference(other Automaton) (Automaton, bool) {
+	return a.product(other, func(accepted bool, otherAccepted bool) bool {
+		return accepted && !otherAccepted
+	})
+}
+
+// Complement returns an automaton accepting the strings of the given length over the alphabet
+// that are not accepted by a.
+func (a Automaton) Complement(length int) Automaton {
+	complement, _ := MakeLengthAutomaton(a.Alphabet, length).Difference(a)
+	return complement
+}
+
+// MakeLengthAutomaton returns an automaton accepting all strings of the given length over the alphabet.
+func MakeLengthAutomaton(alphabet collection.Alphabet, length int) Automaton {
+	automaton := Automaton{Alphabet: alphabet.Copy()}
+	for depth := range length + 1 {
+		next := make([]int, alphabet.Len())
+		for num := range next {
+			next[num] = -1
+			if depth < length {
+				next[num] = depth + 1
+			}
+		}
+		automaton.Next = append(automaton.Next, next)
+		automaton.Accepting = append(automaton.Accepting, depth == length)
+	}
+	return automaton
+}
+
+// product builds the reachable part of the product automaton, where accepts combines the acceptance of both states.
+// The rejecting state -1 takes part in the product as a sink.
+func (a Automaton) product(other Automaton, accepts func(bool, bool) bool) (Automaton, bool) {
+	if !sameAlphabet(a.Alphabet, other.Alphabet) {
+		return Automaton{}, false
+	}
+	step := func(b Automaton, state int, num int) int {
+		if state == -1 {
+			return -1
+		}
+		return b.Next[state][num]
+	}
+	accepting := func(b Automaton, state int) bool {
+		return state != -1 && b.Accepting[state]
+	}
+
+	automaton := Automaton{Alphabet: a.Alphabet.Copy()}
+	index := make(map[statePair]int)
+	var queue []statePair
+	addState := func(pair statePair) int {
+		if num, ok := index[pair]; ok {
+			return num
+		}
+		num := len(queue)
+		index[pair] = num
+		queue = append(queue, pair)
+		automaton.Next = append(automaton.Next, nil)
+		automaton.Accepting = append(automaton.Accepting, accepts(accepting(a, pair.state), accepting(other, pair.otherState)))
+		return num
+	}
+	automaton.Start = addState(statePair{a.Start, other.Start})
+	for num := 0; num < len(queue); num++ {
+		pair := queue[num]
+		next := make([]int, a.Alphabet.Len())
+		for c := range next {
+			nextPair := statePair{step(a, pair.state, c), step(other, pair.otherState, c)}
+			if nextPair.state == -1 && nextPair.otherState == -1 {
+				next[c] = -1
+				continue
+			}
+			next[c] = addState(nextPair)
+		}
+		automaton.Next[num] = next
+	}
+	return automaton.trim(), true
+}
+
+// sameAlphabet checks, whether both alphabets number the same characters in the same way.
+func sameAlphabet(alphabet collection.Alphabet, other collection.Alphabet) bool {
+	if alphabet.Len() != other.Len() {
+		return false
+	}
+	for num := range alphabet.Len() {
+		char, _ := alphabet.Char(num)
+		otherChar, _ := other.Char(num)
+		if char != otherChar {
+			return false
+		}
+	}
+	return true
+}
+
+// trim removes all states from which no accepting state can be reached. The start state is always kept.
+func (a Automaton) trim() Automaton {
+	live := slices.Clone(a.Accepting)
+	for changed := true; changed; {
+		changed = false
+		for state, next := range a.Next {
+			for _, nextState := range next {
+				if !live[state] && nextState != -1 && live[nextState] {
+					live[state] = true
+					changed = true
+				}
+			}
+		}
+	}
+	live[a.Start] = true
+
+	renumber := make([]int, a.Len())
+	trimmed := Automaton{Alphabet: a.Alphabet.Copy()}
+	for state := range a.Next {
+		renumber[state] = -1
+		if live[state] {
+			renumber[state] = len(trimmed.Accepting)
+			trimmed.Accepting = append(trimmed.Accepting, a.Accepting[state])
+		}
+	}
+	for state, next := range a.Next {
+		if !live[state] {
+			continue
+		}
+		trimmedNext := make([]int, len(next))
+		for num, nextState := range next {
+			trimmedNext[num] = -1
+			if nextState != -1 {
+				trimmedNext[num] = renumber[nextState]
+			}
+		}
+		trimmed.Next = append(trimmed.Next, trimmedNext)
+	}
+	trimmed.Start = renumber[a.Start]
+	return trimmed
+}
+
+// RegexNode converts the automaton into a rule tree by state elimination.
+// Fails if the automaton accepts nothing or has too many states.
+func (a Automaton) RegexNode() (RegexNode, bool) {
+	trimmed := a.trim()
+	if trimmed.Len() > maxEliminationStates {
+		return RegexNode{}, false
+	}
+
+	// states 0 to n-1 are the automaton states, n is a new start and n+1 a new final state
+	n := trimmed.Len()
+	edges := make([][]*RegexNode, n+2)
+	for i := range edges {
+		edges[i] = make([]*RegexNode, n+2)
+	}
+	addEdge := func(from int, to int, node RegexNode) {
+		if edges[from][to] != nil {
+			node = simplifyAlternatives([]RegexNode{*edges[from][to], node})
+		}
+		edges[from][to] = &node
+	}
+	empty := RegexNode{Type: Concatenation}
+	addEdge(n, trimmed.Start, empty)
+	for state, next := range trimmed.Next {
+		if trimmed.Accepting[state] {
+			addEdge(state, n+1, empty)
+		}
+		for num, nextState := range next {
+			if nextState != -1 {
+				char, _ := trimmed.Alphabet.Char(num)
+				addEdge(state, nextState, RegexNode{Type: Literal, Value: string(char)})
+			}
+		}
+	}
+
+	eliminated := make([]bool, n)
+	for range n {
+		// eliminate the state with the fewest paths through it first
+		best, bestCost := -1, 0
+		for state := range n {
+			if eliminated[state] {
+				continue
+			}
+			in, out := 0, 0
+			for other := range n + 2 {
+				if other != state && edges[other][state] != nil {
+					in++
+				}
+				if other != state && edges[state][other] != nil {
+					out++
+				}
+			}
+			if best == -1 || in*out < bestCost {
+				best, bestCost = state, in*out
+			}
+		}
+		eliminated[best] = true
+
+		var loop []RegexNode
+		if edges[best][best] != nil {
+			loop = []RegexNode{{Type: Repetition, Value: "*", Children: []RegexNode{*edges[best][best]}}}
+		}
+		for from := range n + 2 {
+			if from == best || edges[from][best] == nil {
+				continue
+			}
+			for to := range n + 2 {
+				if to == best || edges[best][to] == nil {
+					continue
+				}
+				parts := append(slices.Clone(edges[from][best].sequence()), loop...)
+				parts = append(parts, edges[best][to].sequence()...)
+				addEdge(from, to, RegexNode{Type: Concatenation, Children: parts}.simplifyStructure())
+			}
+		}
+		for other := range n + 2 {
+			edges[other][best] = nil
+			edges[best][other] = nil
+		}
+	}
+
+	if edges[n][n+1] == nil {
+		return RegexNode{}, false
+	}
+	return *edges[n][n+1], true
+}
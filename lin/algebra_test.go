@@ -0,0 +1,73 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+	"testing"
+)
+
+func TestAutomaton_Algebra(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	rules := []string{"(ab)*(ba)*", "a.*c", "[ab]+c?", ".*(aa|cc).*", "(.)\\1.?"}
+	for _, rule := range rules {
+		for _, other := range rules {
+			automaton, _ := CompileLineAutomaton(rule, alphabet, 3)
+			otherAutomaton, _ := CompileLineAutomaton(other, alphabet, 3)
+			intersection, ok := automaton.Intersect(otherAutomaton)
+			if !ok {
+				t.Fatalf("Intersect fails on the same alphabet.")
+			}
+			difference, _ := automaton.Difference(otherAutomaton)
+			complement := automaton.Complement(3)
+			for length := range 5 {
+				candidate, ok := MakeCandidateFirst(alphabet, length)
+				for ; ok; candidate, ok = candidate.IncrementCandidate() {
+					accepted := MatchRule(rule, candidate.String())
+					otherAccepted := MatchRule(other, candidate.String())
+					if length == 3 && intersection.Accepts(candidate.Content) != (accepted && otherAccepted) {
+						t.Errorf("Intersect of %s and %s is incorrect on %s.", rule, other, candidate.String())
+					}
+					if length == 3 && difference.Accepts(candidate.Content) != (accepted && !otherAccepted) {
+						t.Errorf("Difference of %s and %s is incorrect on %s.", rule, other, candidate.String())
+					}
+					if complement.Accepts(candidate.Content) != (length == 3 && !accepted) {
+						t.Errorf("Complement of %s is incorrect on %s.", rule, candidate.String())
+					}
+				}
+			}
+		}
+	}
+
+	a, _ := CompileLineAutomaton("a", alphabet, 1)
+	otherA, _ := CompileLineAutomaton("a", collection.MakeAlphabet("ab"), 1)
+	_, ok := a.Intersect(otherA)
+	if ok {
+		t.Errorf("Intersect accepts automata with different alphabets.")
+	}
+}
+
+func TestAutomaton_RegexNode(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	rules := []string{"(ab)*(ba)*", "a.*c", "[ab]+c?", ".*(aa|cc).*", "a|b|c", ""}
+	for _, rule := range rules {
+		automaton, _ := CompileAutomaton(rule, alphabet)
+		node, ok := automaton.RegexNode()
+		if !ok {
+			t.Fatalf("RegexNode fails on %s.", rule)
+		}
+		for length := range 6 {
+			candidate, ok := MakeCandidateFirst(alphabet, length)
+			for ; ok; candidate, ok = candidate.IncrementCandidate() {
+				if MatchRule(node.String(), candidate.String()) != automaton.Accepts(candidate.Content) {
+					t.Errorf("RegexNode %s of %s is incorrect on %s.", node, rule, candidate.String())
+				}
+			}
+		}
+	}
+
+	a, _ := CompileLineAutomaton("a", alphabet, 1)
+	b, _ := CompileLineAutomaton("b", alphabet, 1)
+	empty, _ := a.Intersect(b)
+	if _, ok := empty.RegexNode(); ok {
+		t.Errorf("RegexNode does not fail on an empty language.")
+	}
+}
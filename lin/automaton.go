@@ -0,0 +1,307 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+	"iter"
+	"math"
+	"regexp/syntax"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// maxAutomatonStates bounds the subset construction. Rules exceeding it are left to the bruteforce solver.
+const maxAutomatonStates = 1 << 14
+
+// Automaton is a deterministic finite automaton over the numbers of an alphabet.
+// A transition to -1 means that the input is rejected.
+type Automaton struct {
+	Alphabet  collection.Alphabet
+	Start     int
+	Next      [][]int
+	Accepting []bool
+}
+
+// nfaState is an instruction of a compiled regex program together with the last consumed character.
+// The character is needed to evaluate empty-width assertions like ^, $ and \b.
+type nfaState struct {
+	pc   int
+	prev int
+}
+
+// CompileAutomaton compiles a rule into an automaton accepting exactly the strings over the alphabet
+// that match the complete rule.
+// Fails if the rule is no valid regex or if the automaton gets too large.
+func CompileAutomaton(rule string, alphabet collection.Alphabet) (Automaton, bool) {
+	re, err := syntax.Parse(rule, syntax.Perl)
+	if err != nil {
+		return Automaton{}, false
+	}
+	prog, err := syntax.Compile(re.Simplify())
+	if err != nil {
+		return Automaton{}, false
+	}
+
+	runes := alphabetRunes(alphabet)
+	automaton := Automaton{Alphabet: alphabet.Copy()}
+	index := make(map[string]int)
+	var queue [][]nfaState
+
+	addState := func(states []nfaState) int {
+		states, key := canonicalNfaStates(states)
+		if num, ok := index[key]; ok {
+			return num
+		}
+		num := len(automaton.Next)
+		index[key] = num
+		automaton.Next = append(automaton.Next, nil)
+		automaton.Accepting = append(automaton.Accepting, false)
+		queue = append(queue, states)
+		return num
+	}
+
+	automaton.Start = addState([]nfaState{{prog.Start, -1}})
+	for num := 0; num < len(queue); num++ {
+		if len(queue) > maxAutomatonStates {
+			return Automaton{}, false
+		}
+		states := queue[num]
+
+		for _, state := range states {
+			_, match := progClosure(prog, state.pc, syntax.EmptyOpContext(prevRune(runes, state.prev), -1))
+			if match {
+				automaton.Accepting[num] = true
+				break
+			}
+		}
+
+		next := make([]int, len(runes))
+		for c, char := range runes {
+			var successors []nfaState
+			for _, state := range states {
+				consumers, _ := progClosure(prog, state.pc, syntax.EmptyOpContext(prevRune(runes, state.prev), char))
+				for _, pc := range consumers {
+					if instMatchRune(&prog.Inst[pc], char) {
+						successors = append(successors, nfaState{int(prog.Inst[pc].Out), c})
+					}
+				}
+			}
+			if len(successors) == 0 {
+				next[c] = -1
+				continue
+			}
+			next[c] = addState(successors)
+		}
+		automaton.Next[num] = next
+	}
+	return automaton, true
+}
+
+// Len returns the number of states of the automaton.
+func (a Automaton) Len() int {
+	return len(a.Next)
+}
+
+// Accepts checks, whether the automaton accepts a content over its alphabet.
+func (a Automaton) Accepts(content Content) bool {
+	state := a.Start
+	for _, num := range content {
+		if num < 0 || num >= a.Alphabet.Len() {
+			return false
+		}
+		state = a.Next[state][num]
+		if state == -1 {
+			return false
+		}
+	}
+	return a.Accepting[state]
+}
+
+// SolveDomain narrows each position of a domain over the alphabet of the automaton
+// to the characters that occur there in some accepted string.
+func (a Automaton) SolveDomain(domain Domain) (Domain, int) {
+	sets, solutionNum := a.solve(domain.Sets)
+	return Domain{sets, domain.Alphabet}, solutionNum
+}
+
+// solve narrows the sets of allowed numbers at each position to the numbers which occur in some accepted string,
+// and counts these strings. The count saturates at math.MaxInt.
+func (a Automaton) solve(allowed []collection.Set) ([]collection.Set, int) {
+	length := len(allowed)
+
+	// count[i][state] is the number of allowed suffixes from position i that are accepted starting in state
+	count := make([][]int, length+1)
+	count[length] = make([]int, a.Len())
+	for state, accepting := range a.Accepting {
+		if accepting {
+			count[length][state] = 1
+		}
+	}
+	for i := length - 1; i >= 0; i-- {
+		count[i] = make([]int, a.Len())
+		for state := range a.Next {
+			for num, next := range a.Next[state] {
+				if next != -1 && allowed[i].Contains(num) {
+					count[i][state] = saturatingAdd(count[i][state], count[i+1][next])
+				}
+			}
+		}
+	}
+
+	possible := make([]collection.Set, length)
+	reachable := make([]bool, a.Len())
+	reachable[a.Start] = count[0][a.Start] > 0
+	for i := range length {
+		nextReachable := make([]bool, a.Len())
+		for state, ok := range reachable {
+			if !ok {
+				continue
+			}
+			for num, next := range a.Next[state] {
+				if next != -1 && allowed[i].Contains(num) && count[i+1][next] > 0 {
+					possible[i] = possible[i].Insert(num)
+					nextReachable[next] = true
+				}
+			}
+		}
+		reachable = nextReachable
+	}
+	return possible, count[0][a.Start]
+}
+
+// Live computes for each position i the states from which an allowed suffix starting at position i is accepted.
+func (a Automaton) Live(allowed []collection.Set) [][]bool {
+	length := len(allowed)
+	live := make([][]bool, length+1)
+	live[length] = append([]bool(nil), a.Accepting...)
+	for i := length - 1; i >= 0; i-- {
+		live[i] = make([]bool, a.Len())
+		for state, next := range a.Next {
+			for num, nextState := range next {
+				if nextState != -1 && allowed[i].Contains(num) && live[i+1][nextState] {
+					live[i][state] = true
+					break
+				}
+			}
+		}
+	}
+	return live
+}
+
+// contents enumerates all accepted contents whose numbers are allowed at their position in increasing order.
+func (a Automaton) contents(allowed []collection.Set) iter.Seq[Content] {
+	return func(yield func(Content) bool) {
+		live := a.Live(allowed)
+		if !live[0][a.Start] {
+			return
+		}
+		content := make(Content, len(allowed))
+		var walk func(i, state int) bool
+		walk = func(i, state int) bool {
+			if i == len(allowed) {
+				return yield(content.Copy())
+			}
+			for _, num := range allowed[i].Numbers() {
+				next := a.Next[state][num]
+				if next == -1 || !live[i+1][next] {
+					continue
+				}
+				content[i] = num
+				if !walk(i+1, next) {
+					return false
+				}
+			}
+			return true
+		}
+		walk(0, a.Start)
+	}
+}
+
+func saturatingAdd(a, b int) int {
+	if a > math.MaxInt-b {
+		return math.MaxInt
+	}
+	return a + b
+}
+
+// alphabetRunes returns the characters of an alphabet ordered by their numbers.
+func alphabetRunes(alphabet collection.Alphabet) []rune {
+	runes := make([]rune, alphabet.Len())
+	for num := range runes {
+		runes[num], _ = alphabet.Char(num)
+	}
+	return runes
+}
+
+func prevRune(runes []rune, num int) rune {
+	if num == -1 {
+		return -1
+	}
+	return runes[num]
+}
+
+// progClosure follows all non-consuming instructions starting at pc.
+// It returns the reachable rune-consuming instructions and whether a match is reachable.
+func progClosure(prog *syntax.Prog, pc int, context syntax.EmptyOp) ([]int, bool) {
+	var consumers []int
+	match := false
+	seen := make(map[int]bool)
+	stack := []int{pc}
+	for len(stack) > 0 {
+		pc = stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		if seen[pc] {
+			continue
+		}
+		seen[pc] = true
+
+		inst := &prog.Inst[pc]
+		switch inst.Op {
+		case syntax.InstMatch:
+			match = true
+		case syntax.InstAlt, syntax.InstAltMatch:
+			stack = append(stack, int(inst.Arg), int(inst.Out))
+		case syntax.InstCapture, syntax.InstNop:
+			stack = append(stack, int(inst.Out))
+		case syntax.InstEmptyWidth:
+			if syntax.EmptyOp(inst.Arg)&^context == 0 {
+				stack = append(stack, int(inst.Out))
+			}
+		case syntax.InstRune, syntax.InstRune1, syntax.InstRuneAny, syntax.InstRuneAnyNotNL:
+			consumers = append(consumers, pc)
+		}
+	}
+	slices.Sort(consumers)
+	return consumers, match
+}
+
+func instMatchRune(inst *syntax.Inst, char rune) bool {
+	switch inst.Op {
+	case syntax.InstRuneAny:
+		return true
+	case syntax.InstRuneAnyNotNL:
+		return char != '\n'
+	default:
+		return inst.MatchRune(char)
+	}
+}
+
+// canonicalNfaStates sorts and deduplicates a set of nfa states and returns it with a key identifying the set.
+func canonicalNfaStates(states []nfaState) ([]nfaState, string) {
+	slices.SortFunc(states, func(a, b nfaState) int {
+		if a.pc != b.pc {
+			return a.pc - b.pc
+		}
+		return a.prev - b.prev
+	})
+	states = slices.Compact(states)
+
+	var key strings.Builder
+	for _, state := range states {
+		key.WriteString(strconv.Itoa(state.pc))
+		key.WriteByte(':')
+		key.WriteString(strconv.Itoa(state.prev))
+		key.WriteByte(',')
+	}
+	return states, key.String()
+}
@@ -0,0 +1,56 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+	"testing"
+)
+
+func TestAutomaton_Accepts(t *testing.T) {
+	alphabet := collection.MakeAlphabet("ab")
+	automaton, ok := CompileAutomaton("(ab)*(ba)*", alphabet)
+	if !ok {
+		t.Fatalf("CompileAutomaton failed on a valid rule.")
+	}
+	for row, expected := range map[string]bool{"": true, "ab": true, "abba": true, "ababba": true, "ababbb": false, "baab": false} {
+		content, _ := MakeContent(row, alphabet)
+		if automaton.Accepts(content) != expected {
+			t.Errorf("Accepts is incorrect on %s. Expected %t.", row, expected)
+		}
+	}
+
+	_, ok = CompileAutomaton("(ab", alphabet)
+	if ok {
+		t.Errorf("CompileAutomaton accepts an invalid rule.")
+	}
+}
+
+func TestAutomaton_EmptyWidth(t *testing.T) {
+	alphabet := collection.MakeAlphabet("a b")
+	automaton, _ := CompileAutomaton(`a\b.*|^b$`, alphabet)
+	for row, expected := range map[string]bool{"a b": true, "a": true, "aa": false, "b": true, "bb": false} {
+		content, _ := MakeContent(row, alphabet)
+		if automaton.Accepts(content) != expected {
+			t.Errorf("Accepts is incorrect on %q. Expected %t.", row, expected)
+		}
+	}
+}
+
+func TestCrossword_SolveMatchesBruteforce(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	rules := []string{"(ab)*(ba)*", "a.*c", "[ab]+c?", "(a|bc)+", "a{2}b*|c{3,}", "^$", "(a|b)\\b", ".*(aa|cc).*"}
+	constraints := []string{"", "......", "a.....", "..b..c", ".c.", "aa.", "...."}
+	for _, rule := range rules {
+		crossword := MakeCrossword(rule, alphabet)
+		for _, constraint := range constraints {
+			candidate := MakeCandidate(constraint, '.')
+			expected, expectedCount := crossword.SolveBruteforce(candidate)
+			actual, actualCount := crossword.Solve(candidate)
+			if actualCount != expectedCount {
+				t.Errorf("Solve on %s with %s found %d solutions, expected %d.", rule, constraint, actualCount, expectedCount)
+			}
+			if actual.String() != expected.String() {
+				t.Errorf("Solve on %s with %s is incorrect. Expected %s, got %s.", rule, constraint, expected.String(), actual.String())
+			}
+		}
+	}
+}
@@ -1,10 +1,16 @@
 package lin
 
 import (
+	"context"
 	"crossmatcher/collection"
+	"crossmatcher/matcher"
+	"iter"
 	"regexp"
 )
 
+// contextCheckInterval is the number of candidates SolveBruteforceContext checks between looking at its context.
+const contextCheckInterval = 1024
+
 type Crossword struct {
 	Rule     string
 	Alphabet collection.Alphabet
@@ -17,29 +23,173 @@ func MakeCrossword(rule string, alphabet collection.Alphabet) Crossword {
 
 // CheckSolution checks, whether a candidate without wildcards satisfies a crossword.
 func (crossword Crossword) CheckSolution(candidate Candidate) bool {
-	if candidate.CountWildcards() > 0 {
-		return false
+	return crossword.solutionChecker()(candidate)
+}
+
+// solutionChecker does the same as CheckSolution, but compiles the rule only once for all checked candidates.
+func (crossword Crossword) solutionChecker() func(Candidate) bool {
+	match, _ := compileRule(crossword.Rule)
+	return func(candidate Candidate) bool {
+		return candidate.CountWildcards() == 0 && match(candidate.String())
 	}
-	row := candidate.String()
-	rowRule := "^(" + crossword.Rule + ")$"
-	matched, _ := regexp.MatchString(rowRule, row)
-	return matched
+}
+
+// MatchRule checks, whether a rule matches the whole row.
+// Rules rejected by Go regexp, e.g. with backreferences or lookaheads, are checked by the backtracking matcher.
+func MatchRule(rule string, row string) bool {
+	match, _ := compileRule(rule)
+	return match(row)
+}
+
+// compileRule compiles a rule for MatchRule, picking the engine once.
+// Fails if neither engine accepts the rule, the returned function then matches no row.
+func compileRule(rule string) (func(string) bool, bool) {
+	if re, err := regexp.Compile("^(" + rule + ")$"); err == nil {
+		return re.MatchString, true
+	}
+	pattern, err := matcher.Compile(rule)
+	if err != nil {
+		return func(string) bool { return false }, false
+	}
+	return pattern.Match, true
 }
 
 // SolveBruteforce checks all candidates that fill the wildcards given by the constraint.
 func (crossword Crossword) SolveBruteforce(constraint Candidate) (Candidate, int) {
+	solution, solutionNum, _ := crossword.SolveBruteforceContext(context.Background(), constraint)
+	return solution, solutionNum
+}
+
+// SolveBruteforceContext does the same as SolveBruteforce, but stops when the context is done.
+// It then returns the context error, the unchanged constraint and the number of solutions found so far.
+func (crossword Crossword) SolveBruteforceContext(ctx context.Context, constraint Candidate) (Candidate, int, error) {
 	numWildcards := constraint.CountWildcards()
 	candidateFill, _ := MakeCandidateFirst(crossword.Alphabet, numWildcards)
 	candidateIsValid := true
 	solutionNum := 0
 	var solution Candidate
-	for candidateIsValid {
+	checkSolution := crossword.solutionChecker()
+	for checked := 0; candidateIsValid; checked++ {
+		if checked%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return constraint.Copy(), solutionNum, err
+			}
+		}
 		candidateMerge, _ := constraint.Merge(candidateFill)
-		if crossword.CheckSolution(candidateMerge) {
+		if checkSolution(candidateMerge) {
 			solutionNum++
 			solution, _ = solution.GreatestCommonPattern(candidateMerge)
 		}
 		candidateFill, candidateIsValid = candidateFill.IncrementCandidate()
 	}
-	return solution, solutionNum
+	return solution, solutionNum, nil
+}
+
+// Solve finds all characters that are possible at each wildcard given by the constraint.
+// It returns the same result as SolveBruteforce, but walks the automaton of the rule instead of all fillings.
+func (crossword Crossword) Solve(constraint Candidate) (Candidate, int) {
+	domain, solutionNum := crossword.SolveDomain(MakeDomain(constraint, crossword.Alphabet))
+	if solutionNum == 0 {
+		return Candidate{}, 0
+	}
+	return domain.Candidate(), solutionNum
+}
+
+// SolveDomain narrows each position of the domain to the characters that occur there in some solution.
+// Rules that can not be compiled into an automaton are filled by the backtracking matcher,
+// rules unknown to both are checked on every filling of the domain.
+func (crossword Crossword) SolveDomain(domain Domain) (Domain, int) {
+	automaton, ok := CompileAutomaton(crossword.Rule, domain.Alphabet)
+	if ok {
+		return automaton.SolveDomain(domain)
+	}
+	solutions, ok := crossword.matcherSolutions(domain)
+	if !ok {
+		return crossword.solveDomainBruteforce(domain)
+	}
+	sets := make([]collection.Set, domain.Len())
+	solutionNum := 0
+	for content := range solutions {
+		solutionNum++
+		for i, num := range content {
+			sets[i] = sets[i].Insert(num)
+		}
+	}
+	return Domain{sets, domain.Alphabet}, solutionNum
+}
+
+// matcherSolutions enumerates the distinct contents of the domain matched by the backtracking matcher.
+// Fails if the matcher does not support the rule.
+func (crossword Crossword) matcherSolutions(domain Domain) (iter.Seq[Content], bool) {
+	pattern, err := matcher.Compile(crossword.Rule)
+	if err != nil {
+		return nil, false
+	}
+	cells := make([][]rune, domain.Len())
+	for i, set := range domain.Sets {
+		for _, num := range set.Numbers() {
+			char, _ := domain.Alphabet.Char(num)
+			cells[i] = append(cells[i], char)
+		}
+	}
+	return func(yield func(Content) bool) {
+		for row := range pattern.Fill(cells) {
+			content := make(Content, len(row))
+			for i, char := range row {
+				content[i], _ = domain.Alphabet.Number(char)
+			}
+			if !yield(content) {
+				return
+			}
+		}
+	}, true
+}
+
+// solveDomainBruteforce checks all candidates that fill the domain.
+func (crossword Crossword) solveDomainBruteforce(domain Domain) (Domain, int) {
+	sets := make([]collection.Set, domain.Len())
+	solutionNum := 0
+	checkSolution := crossword.solutionChecker()
+	for content := range domain.fillings() {
+		if checkSolution(Candidate{content, domain.Alphabet}) {
+			solutionNum++
+			for i, num := range content {
+				sets[i] = sets[i].Insert(num)
+			}
+		}
+	}
+	return Domain{sets, domain.Alphabet}, solutionNum
+}
+
+// Solutions enumerates all candidates that fill the wildcards given by the constraint and satisfy the crossword.
+// The solutions are ordered by the alphabet numbers of their characters, starting at the first position,
+// except for rules that need the backtracking matcher, whose solutions come in matching order.
+func (crossword Crossword) Solutions(constraint Candidate) iter.Seq[Candidate] {
+	domain := MakeDomain(constraint, crossword.Alphabet)
+	return func(yield func(Candidate) bool) {
+		automaton, ok := CompileAutomaton(crossword.Rule, domain.Alphabet)
+		if ok {
+			for content := range automaton.contents(domain.Sets) {
+				if !yield(Candidate{content, domain.Alphabet}) {
+					return
+				}
+			}
+			return
+		}
+		if solutions, ok := crossword.matcherSolutions(domain); ok {
+			for content := range solutions {
+				if !yield(Candidate{content, domain.Alphabet}) {
+					return
+				}
+			}
+			return
+		}
+		checkSolution := crossword.solutionChecker()
+		for content := range domain.fillings() {
+			candidate := Candidate{content, domain.Alphabet}
+			if checkSolution(candidate) && !yield(candidate.Copy()) {
+				return
+			}
+		}
+	}
 }
@@ -1,8 +1,12 @@
 package lin
 
 import (
+	"context"
 	"crossmatcher/collection"
+	"errors"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestCrossword_CheckSolution(t *testing.T) {
@@ -63,3 +67,88 @@ func TestCrossword_SolveBruteforce(t *testing.T) {
 		t.Errorf("SolveBruteforce did not find the correct first row. Expected %s, got %s", "ab....", row)
 	}
 }
+
+func TestCrossword_Solve(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abcde")
+	crossword := MakeCrossword("(ab|cd)*e(ab|cd)*", alphabet)
+	candidate := MakeCandidate("...........a", '.')
+	solution, count := crossword.Solve(candidate)
+	if count != 0 {
+		t.Errorf("Solve did not find the correct number of solutions. Expected %d, got %d", 0, count)
+	}
+	candidate = MakeCandidate("..........e.", '.')
+	solution, count = crossword.Solve(candidate)
+	if count != 0 {
+		t.Errorf("Solve did not find the correct number of solutions. Expected %d, got %d", 0, count)
+	}
+	candidate = MakeCandidate("....e......", '.')
+	solution, count = crossword.Solve(candidate)
+	if count != 32 {
+		t.Errorf("Solve did not find the correct number of solutions. Expected %d, got %d", 32, count)
+	}
+	row := solution.String()
+	if row != "....e......" {
+		t.Errorf("Solve did not find the correct row. Expected %s, got %s", "....e......", row)
+	}
+	candidate = MakeCandidate("a...e.....d", '.')
+	solution, _ = crossword.Solve(candidate)
+	row = solution.String()
+	if row != "ab..e....cd" {
+		t.Errorf("Solve did not find the correct row. Expected %s, got %s", "ab..e....cd", row)
+	}
+}
+
+func TestCrossword_Solutions(t *testing.T) {
+	alphabet := collection.MakeAlphabet("ab")
+	crossword := MakeCrossword("(ab)*(ba)*", alphabet)
+	var rows []string
+	for solution := range crossword.Solutions(MakeCandidate("a.....", '.')) {
+		rows = append(rows, solution.String())
+	}
+	expected := []string{"ababab", "ababba", "abbaba"}
+	if !slices.Equal(rows, expected) {
+		t.Errorf("Solutions is incorrect. Expected %v, got %v", expected, rows)
+	}
+
+	count := 0
+	for range MakeCrossword(".*", alphabet).Solutions(MakeCandidateEmpty(alphabet, 40)) {
+		count++
+		if count == 5 {
+			break
+		}
+	}
+	if count != 5 {
+		t.Errorf("Solutions did not stop after the first %d solutions, got %d", 5, count)
+	}
+}
+
+func TestCrossword_SolveBruteforceContext(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abcde")
+	crossword := MakeCrossword(".*", alphabet)
+	candidate := MakeCandidate("a.............", '.')
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	partial, _, err := crossword.SolveBruteforceContext(ctx, candidate)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SolveBruteforceContext does not report the deadline, got %v", err)
+	}
+	if partial.String() != candidate.String() {
+		t.Errorf("SolveBruteforceContext does not return the constraint. Expected %s, got %s", candidate.String(), partial.String())
+	}
+}
+
+func TestCrossword_SolveBackreference(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	crossword := MakeCrossword(`(.)(.)\2\1|(?!a).c`, alphabet)
+	if !crossword.CheckSolution(MakeCandidate("abba", '.')) || crossword.CheckSolution(MakeCandidate("abab", '.')) {
+		t.Errorf("CheckSolution is incorrect on a rule with a backreference.")
+	}
+	for _, constraint := range []string{"....", "a...", ".b..", "..", "a."} {
+		candidate := MakeCandidate(constraint, '.')
+		expected, expectedCount := crossword.SolveBruteforce(candidate)
+		actual, actualCount := crossword.Solve(candidate)
+		if actualCount != expectedCount || actual.String() != expected.String() {
+			t.Errorf("Solve with %s is incorrect. Expected %s with %d solutions, got %s with %d.", constraint, expected.String(), expectedCount, actual.String(), actualCount)
+		}
+	}
+}
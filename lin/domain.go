@@ -0,0 +1,133 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+	"iter"
+)
+
+// Domain holds the set of still possible alphabet numbers for each position of a line.
+type Domain struct {
+	Sets     []collection.Set
+	Alphabet collection.Alphabet
+}
+
+// MakeDomain makes a domain from a candidate.
+// Fixed characters are kept, wildcards may become any character of the fill alphabet.
+func MakeDomain(candidate Candidate, fill collection.Alphabet) Domain {
+	alphabet := candidate.Alphabet.Merge(fill)
+	var fillSet collection.Set
+	for num := range fill.Len() {
+		char, _ := fill.Char(num)
+		merged, _ := alphabet.Number(char)
+		fillSet = fillSet.Insert(merged)
+	}
+
+	sets := make([]collection.Set, candidate.Len())
+	for i, num := range candidate.Content {
+		if num == -1 {
+			sets[i] = fillSet.Copy()
+		} else {
+			char, _ := candidate.Alphabet.Char(num)
+			merged, _ := alphabet.Number(char)
+			sets[i] = collection.MakeSet(merged)
+		}
+	}
+	return Domain{sets, alphabet}
+}
+
+// Candidate returns the candidate of all decided positions. All other positions become wildcards.
+func (d Domain) Candidate() Candidate {
+	content := make(Content, len(d.Sets))
+	for i, set := range d.Sets {
+		content[i] = -1
+		if num, ok := set.Single(); ok {
+			content[i] = num
+		}
+	}
+	return Candidate{content, d.Alphabet}
+}
+
+// Len returns the length of the line of a domain.
+func (d Domain) Len() int {
+	return len(d.Sets)
+}
+
+// CountOpen returns the number of positions that are not decided yet.
+func (d Domain) CountOpen() int {
+	count := 0
+	for _, set := range d.Sets {
+		if set.Len() != 1 {
+			count++
+		}
+	}
+	return count
+}
+
+// Marks returns the possible characters of each position ordered by their alphabet numbers.
+func (d Domain) Marks() []string {
+	marks := make([]string, len(d.Sets))
+	for i, set := range d.Sets {
+		for _, num := range set.Numbers() {
+			char, _ := d.Alphabet.Char(num)
+			marks[i] += string(char)
+		}
+	}
+	return marks
+}
+
+// Copy creates an exact copy of the domain.
+func (d Domain) Copy() Domain {
+	sets := make([]collection.Set, len(d.Sets))
+	for i, set := range d.Sets {
+		sets[i] = set.Copy()
+	}
+	return Domain{sets, d.Alphabet.Copy()}
+}
+
+// Equal checks, whether both domains allow the same numbers at each position.
+func (d Domain) Equal(other Domain) bool {
+	if len(d.Sets) != len(other.Sets) {
+		return false
+	}
+	for i := range d.Sets {
+		if !d.Sets[i].Equal(other.Sets[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// fillings enumerates all contents choosing one number of each set of the domain.
+// The yielded content is reused between iterations.
+func (d Domain) fillings() iter.Seq[Content] {
+	return func(yield func(Content) bool) {
+		numbers := make([][]int, d.Len())
+		for i, set := range d.Sets {
+			numbers[i] = set.Numbers()
+			if len(numbers[i]) == 0 {
+				return
+			}
+		}
+
+		choice := make([]int, d.Len())
+		content := make(Content, d.Len())
+		for {
+			for i := range content {
+				content[i] = numbers[i][choice[i]]
+			}
+			if !yield(content) {
+				return
+			}
+
+			i := 0
+			for i < len(choice) && choice[i] == len(numbers[i])-1 {
+				choice[i] = 0
+				i++
+			}
+			if i == len(choice) {
+				return
+			}
+			choice[i]++
+		}
+	}
+}
@@ -0,0 +1,32 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+	"slices"
+	"testing"
+)
+
+func TestDomain_MakeDomain(t *testing.T) {
+	alphabet := collection.MakeAlphabet("ab")
+	domain := MakeDomain(MakeCandidate("b.", '.'), alphabet)
+	if domain.CountOpen() != 1 {
+		t.Errorf("MakeDomain is incorrect. Expected %d open positions, got %d", 1, domain.CountOpen())
+	}
+	actual := domain.Candidate().String()
+	if actual != "b." {
+		t.Errorf("Candidate is incorrect. Expected %s, got %s", "b.", actual)
+	}
+}
+
+func TestCrossword_SolveDomain(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	crossword := MakeCrossword("(ab|ba)c*", alphabet)
+	domain, count := crossword.SolveDomain(MakeDomain(MakeCandidateEmpty(alphabet, 3), alphabet))
+	if count != 2 {
+		t.Errorf("SolveDomain did not find the correct number of solutions. Expected %d, got %d", 2, count)
+	}
+	expected := []string{"ab", "ab", "c"}
+	if !slices.Equal(domain.Marks(), expected) {
+		t.Errorf("SolveDomain is incorrect. Expected %v, got %v", expected, domain.Marks())
+	}
+}
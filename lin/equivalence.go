@@ -0,0 +1,101 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+)
+
+// Equivalent checks, whether two rules accept the same rows of the given length over the alphabet.
+// Otherwise it also returns a row accepted by only one of the rules. Fails if one of the rules does not compile.
+func Equivalent(rule string, other string, length int, alphabet collection.Alphabet) (bool, string, bool) {
+	return compareRules(rule, other, length, alphabet, func(accepted bool, otherAccepted bool) bool {
+		return accepted != otherAccepted
+	})
+}
+
+// Contained checks, whether every row of the given length over the alphabet accepted by rule is accepted by other.
+// Otherwise it also returns a row accepted by rule, but not by other. Fails if one of the rules does not compile.
+func Contained(rule string, other string, length int, alphabet collection.Alphabet) (bool, string, bool) {
+	return compareRules(rule, other, length, alphabet, func(accepted bool, otherAccepted bool) bool {
+		return accepted && !otherAccepted
+	})
+}
+
+// statePair is a state of the product of two automata, -1 stands for the rejecting sink.
+type statePair struct {
+	state      int
+	otherState int
+}
+
+// compareRules searches the product automaton of both rules, accepting exactly the counterexamples,
+// for a row of the given length. Returns true if there is none.
+// Of all counterexamples it finds the first one in the order of the alphabet numbers.
+func compareRules(rule string, other string, length int, alphabet collection.Alphabet, differs func(bool, bool) bool) (bool, string, bool) {
+	automaton, ok := CompileLineAutomaton(rule, alphabet, length)
+	if !ok {
+		return false, "", false
+	}
+	otherAutomaton, ok := CompileLineAutomaton(other, alphabet, length)
+	if !ok {
+		return false, "", false
+	}
+	counterexamples, ok := automaton.product(otherAutomaton, differs)
+	if !ok {
+		return false, "", false
+	}
+
+	allowed := make([]collection.Set, length)
+	for i := range allowed {
+		allowed[i] = collection.MakeSetRange(alphabet.Len())
+	}
+	live := counterexamples.Live(allowed)
+	if !live[0][counterexamples.Start] {
+		return true, "", true
+	}
+	row := make([]rune, length)
+	state := counterexamples.Start
+	for i := range length {
+		for num, next := range counterexamples.Next[state] {
+			if next != -1 && live[i+1][next] {
+				row[i], _ = alphabet.Char(num)
+				state = next
+				break
+			}
+		}
+	}
+	return false, string(row), true
+}
+
+// CompileLineAutomaton compiles a rule into an automaton that is correct at least on rows of the given length.
+// Rules that CompileAutomaton can not handle are enumerated by the matcher and stored as a trie.
+// Fails if neither Go regexp nor the matcher accepts the rule.
+func CompileLineAutomaton(rule string, alphabet collection.Alphabet, length int) (Automaton, bool) {
+	if automaton, ok := CompileAutomaton(rule, alphabet); ok {
+		return automaton, true
+	}
+	if _, ok := compileRule(rule); !ok {
+		return Automaton{}, false
+	}
+	automaton := Automaton{Alphabet: alphabet.Copy()}
+	addState := func() int {
+		next := make([]int, alphabet.Len())
+		for i := range next {
+			next[i] = -1
+		}
+		automaton.Next = append(automaton.Next, next)
+		automaton.Accepting = append(automaton.Accepting, false)
+		return len(automaton.Next) - 1
+	}
+	automaton.Start = addState()
+	for solution := range MakeCrossword(rule, alphabet).Solutions(MakeCandidateEmpty(alphabet, length)) {
+		state := automaton.Start
+		for _, char := range solution.String() {
+			num, _ := alphabet.Number(char)
+			if automaton.Next[state][num] == -1 {
+				automaton.Next[state][num] = addState()
+			}
+			state = automaton.Next[state][num]
+		}
+		automaton.Accepting[state] = true
+	}
+	return automaton, true
+}
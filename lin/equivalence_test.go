@@ -0,0 +1,67 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+	"testing"
+)
+
+func TestEquivalent(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	for _, test := range []struct {
+		rule       string
+		other      string
+		length     int
+		equivalent bool
+		difference string
+	}{
+		{"(ab|ab|a)+", "(ab?)+", 5, true, ""},
+		{"a*", "[ab]*", 3, false, "aab"},
+		{"(a|b)\\1", "aa|bb", 2, true, ""},
+		{"(a|b)\\1", "aa|bc", 2, false, "bb"},
+		{"a.c|b", "a.c", 3, true, ""},
+		{"a.c|b", "a.c", 1, false, "b"},
+	} {
+		equivalent, difference, ok := Equivalent(test.rule, test.other, test.length, alphabet)
+		if !ok || equivalent != test.equivalent || difference != test.difference {
+			t.Errorf("Equivalent of %s and %s with length %d is incorrect. Expected %t %q, got %t %q.",
+				test.rule, test.other, test.length, test.equivalent, test.difference, equivalent, difference)
+		}
+	}
+}
+
+func TestContained(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	for _, test := range []struct {
+		rule       string
+		other      string
+		contained  bool
+		difference string
+	}{
+		{"a*", "[ab]*", true, ""},
+		{"[ab]*", "a*", false, "aab"},
+		{"(?!c)..c", "[ab].c", true, ""},
+		{"(.)\\1c", "a.c", false, "bbc"},
+	} {
+		contained, difference, ok := Contained(test.rule, test.other, 3, alphabet)
+		if !ok || contained != test.contained || difference != test.difference {
+			t.Errorf("Contained of %s in %s is incorrect. Expected %t %q, got %t %q.",
+				test.rule, test.other, test.contained, test.difference, contained, difference)
+		}
+	}
+}
+
+func TestEquivalent_Invalid(t *testing.T) {
+	alphabet := collection.MakeAlphabet("ab")
+	if _, _, ok := Equivalent("(", "[", 3, alphabet); ok {
+		t.Errorf("Equivalent does not fail on rules that do not compile.")
+	}
+	if _, _, ok := Contained("(", "b+", 3, alphabet); ok {
+		t.Errorf("Contained does not fail on a rule that does not compile.")
+	}
+	if _, _, ok := Contained("b+", "a(", 3, alphabet); ok {
+		t.Errorf("Contained does not fail on another rule that does not compile.")
+	}
+	if _, ok := CompileLineAutomaton("(a", alphabet, 3); ok {
+		t.Errorf("CompileLineAutomaton does not fail on a rule that does not compile.")
+	}
+}
@@ -16,7 +16,7 @@ func NewModel(rule string, alphabetString string, candidate string) *Model {
 }
 
 func (m *Model) Solve() string {
-	candidate, ok := m.crossword.SolveBruteforce(m.candidate)
+	candidate, ok := m.crossword.Solve(m.candidate)
 	if ok == 0 {
 		return strings.Repeat("#", m.candidate.Len())
 	}
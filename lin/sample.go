@@ -0,0 +1,85 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+	"math/big"
+	"math/rand"
+)
+
+// Sample draws a row of the given length uniformly from all rows over the alphabet that satisfy the crossword.
+// Fails if there is no such row.
+func (crossword Crossword) Sample(length int, rng *rand.Rand) (string, bool) {
+	automaton, ok := CompileLineAutomaton(crossword.Rule, crossword.Alphabet, length)
+	if !ok {
+		return "", false
+	}
+	allowed := make([]collection.Set, length)
+	for i := range allowed {
+		allowed[i] = collection.MakeSetRange(crossword.Alphabet.Len())
+	}
+	count := automaton.countExact(allowed)
+	if count[0][automaton.Start].Sign() == 0 {
+		return "", false
+	}
+
+	row := make([]rune, length)
+	state := automaton.Start
+	for i := range length {
+		// pick the character by the share of accepted suffixes behind it
+		pick := new(big.Int).Rand(rng, count[i][state])
+		for num, next := range automaton.Next[state] {
+			if next == -1 {
+				continue
+			}
+			if pick.Cmp(count[i+1][next]) < 0 {
+				row[i], _ = crossword.Alphabet.Char(num)
+				state = next
+				break
+			}
+			pick.Sub(pick, count[i+1][next])
+		}
+	}
+	return string(row), true
+}
+
+// CountMatches returns the exact number of fillings of the wildcards given by the constraint that satisfy the crossword.
+// Rules that can not be compiled into an automaton are counted by enumerating their solutions.
+func (crossword Crossword) CountMatches(constraint Candidate) *big.Int {
+	domain := MakeDomain(constraint, crossword.Alphabet)
+	automaton, ok := CompileAutomaton(crossword.Rule, domain.Alphabet)
+	if !ok {
+		count := big.NewInt(0)
+		one := big.NewInt(1)
+		for range crossword.Solutions(constraint) {
+			count.Add(count, one)
+		}
+		return count
+	}
+	return automaton.countExact(domain.Sets)[0][automaton.Start]
+}
+
+// countExact computes for each position i and state the exact number of allowed suffixes from position i
+// that are accepted starting in the state.
+func (a Automaton) countExact(allowed []collection.Set) [][]*big.Int {
+	length := len(allowed)
+	count := make([][]*big.Int, length+1)
+	count[length] = make([]*big.Int, a.Len())
+	for state, accepting := range a.Accepting {
+		count[length][state] = big.NewInt(0)
+		if accepting {
+			count[length][state].SetInt64(1)
+		}
+	}
+	for i := length - 1; i >= 0; i-- {
+		count[i] = make([]*big.Int, a.Len())
+		for state := range a.Next {
+			count[i][state] = big.NewInt(0)
+			for num, next := range a.Next[state] {
+				if next != -1 && allowed[i].Contains(num) {
+					count[i][state].Add(count[i][state], count[i+1][next])
+				}
+			}
+		}
+	}
+	return count
+}
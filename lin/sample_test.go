@@ -0,0 +1,58 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+	"math/big"
+	"math/rand"
+	"testing"
+)
+
+func TestCrossword_Sample(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	rng := rand.New(rand.NewSource(1))
+	for rule, expected := range map[string][]string{
+		"a.|bc|(c)\\1": {"aa", "ab", "ac", "bc", "cc"},
+		"b*|a*":        {"aa", "bb"},
+	} {
+		crossword := MakeCrossword(rule, alphabet)
+		counts := make(map[string]int)
+		for range 1000 * len(expected) {
+			row, ok := crossword.Sample(2, rng)
+			if !ok {
+				t.Fatalf("Sample fails on %s.", rule)
+			}
+			counts[row]++
+		}
+		for _, row := range expected {
+			if counts[row] < 850 || counts[row] > 1150 {
+				t.Errorf("Sample of %s is not uniform. Drew %s %d times out of %d.", rule, row, counts[row], 1000*len(expected))
+			}
+		}
+		if len(counts) != len(expected) {
+			t.Errorf("Sample of %s drew rows outside of %v: %v", rule, expected, counts)
+		}
+	}
+
+	if _, ok := MakeCrossword("a{3}", alphabet).Sample(2, rng); ok {
+		t.Errorf("Sample does not fail without matching rows.")
+	}
+}
+
+func TestCrossword_CountMatches(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	for _, rule := range []string{"a.*c", "(ab|c)*", "(.)\\1.", "[^a]+b?"} {
+		crossword := MakeCrossword(rule, alphabet)
+		for _, constraint := range []string{"...", "a..", ".b.c", "....", ""} {
+			candidate := MakeCandidate(constraint, '.')
+			_, expected := crossword.SolveBruteforce(candidate)
+			if actual := crossword.CountMatches(candidate); actual.Int64() != int64(expected) {
+				t.Errorf("CountMatches of %s with %s is incorrect. Expected %d, got %s.", rule, constraint, expected, actual)
+			}
+		}
+	}
+
+	count := MakeCrossword(".*", alphabet).CountMatches(MakeCandidateEmpty(alphabet, 100))
+	if expected := new(big.Int).Exp(big.NewInt(3), big.NewInt(100), nil); count.Cmp(expected) != 0 {
+		t.Errorf("CountMatches is incorrect on a long row. Expected %s, got %s.", expected, count)
+	}
+}
@@ -0,0 +1,176 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+	"math/rand"
+	"regexp"
+	"strings"
+)
+
+// The mutations in this file only broaden a rule, so every row matched before still matches afterwards.
+// They return the rule unchanged if it has no suitable node.
+
+// WidenRandomCharacter replaces a random character by a class of it and another character of the alphabet,
+// or adds another character to a random class
+func (node RegexNode) WidenRandomCharacter(alphabet collection.Alphabet, rng *rand.Rand) RegexNode {
+	ret := node.DeepCopy()
+	refs := ret.characterRefs(func(child RegexNode) bool {
+		return child.Type == Literal || child.Type == CharClass && !strings.HasPrefix(child.Value, "[^")
+	})
+	if len(refs) == 0 {
+		return node
+	}
+	ref := refs[rng.Intn(len(refs))]
+	class := regexp.MustCompile(ref.String())
+	var matched, missing []rune
+	for _, char := range alphabet.String() {
+		if class.MatchString(string(char)) {
+			matched = append(matched, char)
+		} else {
+			missing = append(missing, char)
+		}
+	}
+	if len(missing) == 0 {
+		return node
+	}
+	matched = append(matched, missing[rng.Intn(len(missing))])
+	*ref = RegexNode{Type: CharClass, Value: charactersClass(alphabet, matched, false)}
+	return ret
+}
+
+// NegateRandomCharacter replaces a random character by a negated class of another character of the alphabet
+func (node RegexNode) NegateRandomCharacter(alphabet collection.Alphabet, rng *rand.Rand) RegexNode {
+	ret := node.DeepCopy()
+	refs := ret.characterRefs(func(child RegexNode) bool {
+		return child.Type == Literal
+	})
+	if len(refs) == 0 || alphabet.Len() < 2 {
+		return node
+	}
+	ref := refs[rng.Intn(len(refs))]
+	var others []rune
+	for _, char := range alphabet.String() {
+		if string(char) != ref.Value {
+			others = append(others, char)
+		}
+	}
+	excluded := others[rng.Intn(len(others))]
+	*ref = RegexNode{Type: CharClass, Value: charactersClass(alphabet, []rune{excluded}, true)}
+	return ret
+}
+
+// WildcardRandomCharacter replaces a random character or class by .
+func (node RegexNode) WildcardRandomCharacter(rng *rand.Rand) RegexNode {
+	ret := node.DeepCopy()
+	refs := ret.characterRefs(func(child RegexNode) bool {
+		return child.Type == Literal || child.Type == CharClass
+	})
+	if len(refs) == 0 {
+		return node
+	}
+	*refs[rng.Intn(len(refs))] = RegexNode{Type: AnyChar, Value: "."}
+	return ret
+}
+
+// OptionalRandomCharacter makes a random character, class or . optional by ?
+func (node RegexNode) OptionalRandomCharacter(rng *rand.Rand) RegexNode {
+	return node.quantifyRandomCharacter("?", rng)
+}
+
+// QuantifyRandomCharacter repeats a random character, class or . by {m,n} with m at most 1 and n at least 2
+func (node RegexNode) QuantifyRandomCharacter(rng *rand.Rand) RegexNode {
+	return node.quantifyRandomCharacter(quantifierString(rng.Intn(2), 2+rng.Intn(2)), rng)
+}
+
+func (node RegexNode) quantifyRandomCharacter(quantifier string, rng *rand.Rand) RegexNode {
+	ret := node.DeepCopy()
+	refs := ret.characterRefs(func(child RegexNode) bool {
+		return child.Type == Literal || child.Type == CharClass || child.Type == AnyChar
+	})
+	if len(refs) == 0 {
+		return node
+	}
+	ref := refs[rng.Intn(len(refs))]
+	*ref = RegexNode{Type: Repetition, Value: quantifier, Children: []RegexNode{*ref}}
+	return ret
+}
+
+// StarRandomBlock allows a random block repeated by + to be left out, by repeating it with * instead
+func (node RegexNode) StarRandomBlock(rng *rand.Rand) RegexNode {
+	ret := node.DeepCopy()
+	var refs []*RegexNode
+	ret.walk(func(ref *RegexNode) {
+		if ref.Type == Repetition && ref.Value == "+" {
+			refs = append(refs, ref)
+		}
+	})
+	if len(refs) == 0 {
+		return node
+	}
+	refs[rng.Intn(len(refs))].Value = "*"
+	return ret
+}
+
+// NestRandomRun turns a random run of the parts of an alternation element into a nested block repeated by +,
+// shaped like the blocks of SeparateIntoBlocks, WithAlternationSubgroups and WithRepetitionSubgroups
+func (node RegexNode) NestRandomRun(rng *rand.Rand) RegexNode {
+	ret := node.DeepCopy()
+	var refs []*RegexNode
+	ret.walk(func(ref *RegexNode) {
+		if ref.Type == Concatenation && len(ref.Children) >= 2 {
+			refs = append(refs, ref)
+		}
+	})
+	if len(refs) == 0 {
+		return node
+	}
+	ref := refs[rng.Intn(len(refs))]
+	// the run is shorter than the element, otherwise the nesting would only repeat the whole element
+	length := 1 + rng.Intn(len(ref.Children)-1)
+	start := rng.Intn(len(ref.Children) - length + 1)
+	run := RegexNode{Type: Concatenation, Children: append([]RegexNode(nil), ref.Children[start:start+length]...)}
+	block := RegexNode{Type: Repetition, Value: "+", Children: []RegexNode{{Type: Alternation, Children: []RegexNode{run}}}}
+	children := append([]RegexNode(nil), ref.Children[:start]...)
+	children = append(children, block)
+	ref.Children = append(children, ref.Children[start+length:]...)
+	return ret
+}
+
+// characterRefs returns the children of concatenations that are accepted by the filter.
+// Children of repetitions are left out, so that quantifiers are never stacked.
+func (node *RegexNode) characterRefs(accept func(RegexNode) bool) []*RegexNode {
+	var refs []*RegexNode
+	node.walk(func(ref *RegexNode) {
+		if ref.Type != Concatenation {
+			return
+		}
+		for i := range ref.Children {
+			if accept(ref.Children[i]) {
+				refs = append(refs, &ref.Children[i])
+			}
+		}
+	})
+	return refs
+}
+
+// walk calls visit on the node and all its descendants in preorder
+func (node *RegexNode) walk(visit func(*RegexNode)) {
+	visit(node)
+	for i := range node.Children {
+		node.Children[i].walk(visit)
+	}
+}
+
+// charactersClass prints a class of the characters in the order of the alphabet
+func charactersClass(alphabet collection.Alphabet, chars []rune, negated bool) string {
+	var ranges []rune
+	for _, char := range alphabet.String() {
+		for _, c := range chars {
+			if c == char {
+				ranges = append(ranges, char, char)
+				break
+			}
+		}
+	}
+	return classString(ranges, negated)
+}
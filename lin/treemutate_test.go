@@ -0,0 +1,70 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+	"math/rand"
+	"strings"
+	"testing"
+)
+
+func TestRuleTree_MutationsBroaden(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	rng := rand.New(rand.NewSource(1))
+	// a slice keeps the order of the draws from rng fixed
+	mutations := []struct {
+		name   string
+		mutate func(RegexNode) RegexNode
+	}{
+		{"WidenRandomCharacter", func(node RegexNode) RegexNode { return node.WidenRandomCharacter(alphabet, rng) }},
+		{"NegateRandomCharacter", func(node RegexNode) RegexNode { return node.NegateRandomCharacter(alphabet, rng) }},
+		{"WildcardRandomCharacter", func(node RegexNode) RegexNode { return node.WildcardRandomCharacter(rng) }},
+		{"OptionalRandomCharacter", func(node RegexNode) RegexNode { return node.OptionalRandomCharacter(rng) }},
+		{"QuantifyRandomCharacter", func(node RegexNode) RegexNode { return node.QuantifyRandomCharacter(rng) }},
+		{"StarRandomBlock", func(node RegexNode) RegexNode { return node.StarRandomBlock(rng) }},
+		{"NestRandomRun", func(node RegexNode) RegexNode { return node.NestRandomRun(rng) }},
+	}
+	for _, mutation := range mutations {
+		name, mutate := mutation.name, mutation.mutate
+		changed := false
+		for range 20 {
+			rule := MakeRegexNode("abcab").SeparateIntoBlocksWith(BlockProbabilityAcc(5, 0), rng).
+				WithAlternationSubgroups().WithRepetitionSubgroups()
+			for range 3 {
+				mutated := mutate(rule)
+				if ok, row, _ := Contained(rule.String(), mutated.String(), 5, alphabet); !ok {
+					t.Errorf("%s narrows %s to %s, %s is lost", name, rule, mutated, row)
+				}
+				changed = changed || mutated.String() != rule.String()
+				rule = mutated
+			}
+		}
+		if !changed {
+			t.Errorf("%s never changes a rule", name)
+		}
+	}
+}
+
+func TestRuleTree_MutationsSyntax(t *testing.T) {
+	alphabet := collection.MakeAlphabet("ab")
+	rng := rand.New(rand.NewSource(2))
+	rule := MakeRegexNode("ab").SeparateIntoBlocksWith([]float64{0, 0, 1}, rng).WithAlternationSubgroups().WithRepetitionSubgroups()
+	if actual := rule.NegateRandomCharacter(alphabet, rng).String(); actual != "([^b]b)+" && actual != "(a[^a])+" {
+		t.Errorf("NegateRandomCharacter is incorrect, got %s", actual)
+	}
+	if actual := rule.WidenRandomCharacter(alphabet, rng).String(); actual != "([ab]b)+" && actual != "(a[ab])+" {
+		t.Errorf("WidenRandomCharacter is incorrect, got %s", actual)
+	}
+	if actual := rule.StarRandomBlock(rng).String(); actual != "(ab)*" {
+		t.Errorf("StarRandomBlock is incorrect, got %s", actual)
+	}
+	if actual := rule.NestRandomRun(rng).String(); actual != "(a+b)+" && actual != "(ab+)+" {
+		t.Errorf("NestRandomRun is incorrect, got %s", actual)
+	}
+	if actual := rule.QuantifyRandomCharacter(rng).String(); !strings.Contains(actual, "{") {
+		t.Errorf("QuantifyRandomCharacter is incorrect, got %s", actual)
+	}
+	single := MakeRegexNode("a")
+	if actual := single.NegateRandomCharacter(collection.MakeAlphabet("a"), rng).String(); actual != "a" {
+		t.Errorf("NegateRandomCharacter changes a rule over a single character, got %s", actual)
+	}
+}
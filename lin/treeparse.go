@@ -0,0 +1,104 @@
+package lin
+
+import (
+	"crossmatcher/matcher"
+	"strconv"
+	"strings"
+)
+
+// ParseRegexNode parses a rule into a rule tree. Fails if the rule is not supported by the matcher.
+// Lazy quantifiers are dropped and escapes like \d become character classes.
+func ParseRegexNode(rule string) (RegexNode, bool) {
+	root, _, err := matcher.Parse(rule)
+	if err != nil {
+		return RegexNode{}, false
+	}
+	return makeRegexNodeFromMatcher(root), true
+}
+
+func makeRegexNodeFromMatcher(node *matcher.Node) RegexNode {
+	var children []RegexNode
+	for _, child := range node.Children {
+		children = append(children, makeRegexNodeFromMatcher(child))
+	}
+	switch node.Type {
+	case matcher.Char:
+		return RegexNode{Type: Literal, Value: string(node.Runes[0])}
+	case matcher.Class:
+		return RegexNode{Type: CharClass, Value: classString(node.Runes, node.Negated)}
+	case matcher.Any:
+		return RegexNode{Type: AnyChar, Value: "."}
+	case matcher.Concat:
+		return RegexNode{Type: Concatenation, Children: children}
+	case matcher.Alternate:
+		return RegexNode{Type: Alternation, Children: children}
+	case matcher.Repeat:
+		return RegexNode{Type: Repetition, Value: quantifierString(node.Min, node.Max), Children: children}
+	case matcher.Group:
+		if node.Index == 0 {
+			return RegexNode{Type: Group, Value: "?:", Children: children}
+		}
+		return RegexNode{Type: Group, Children: children}
+	case matcher.Backref:
+		return RegexNode{Type: Backreference, Value: strconv.Itoa(node.Index)}
+	case matcher.Lookahead:
+		if node.Negated {
+			return RegexNode{Type: Lookahead, Value: "!", Children: children}
+		}
+		return RegexNode{Type: Lookahead, Value: "=", Children: children}
+	case matcher.Begin:
+		return RegexNode{Type: Anchor, Value: "^"}
+	default:
+		return RegexNode{Type: Anchor, Value: "$"}
+	}
+}
+
+// classString writes a character class from pairs of rune ranges.
+func classString(ranges []rune, negated bool) string {
+	var builder strings.Builder
+	builder.WriteString("[")
+	if negated {
+		builder.WriteString("^")
+	}
+	for i := 0; i+1 < len(ranges); i += 2 {
+		builder.WriteString(classChar(ranges[i]))
+		if ranges[i+1] != ranges[i] {
+			builder.WriteString("-" + classChar(ranges[i+1]))
+		}
+	}
+	builder.WriteString("]")
+	return builder.String()
+}
+
+func classChar(char rune) string {
+	switch char {
+	case '\\', ']', '[', '^', '-':
+		return "\\" + string(char)
+	case '\n':
+		return `\n`
+	case '\t':
+		return `\t`
+	case '\r':
+		return `\r`
+	case '\f':
+		return `\f`
+	}
+	return string(char)
+}
+
+func quantifierString(minCount int, maxCount int) string {
+	switch {
+	case minCount == 0 && maxCount == -1:
+		return "*"
+	case minCount == 1 && maxCount == -1:
+		return "+"
+	case minCount == 0 && maxCount == 1:
+		return "?"
+	case maxCount == -1:
+		return "{" + strconv.Itoa(minCount) + ",}"
+	case minCount == maxCount:
+		return "{" + strconv.Itoa(minCount) + "}"
+	default:
+		return "{" + strconv.Itoa(minCount) + "," + strconv.Itoa(maxCount) + "}"
+	}
+}
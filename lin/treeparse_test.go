@@ -0,0 +1,52 @@
+package lin
+
+import (
+	"crossmatcher/collection"
+	"testing"
+)
+
+func TestRuleTree_ParseRegexNode(t *testing.T) {
+	node, ok := ParseRegexNode(`(a|b)+[^c]?\1`)
+	if !ok {
+		t.Fatalf("ParseRegexNode failed on a valid rule.")
+	}
+	if node.Type != Concatenation || len(node.Children) != 3 {
+		t.Fatalf("ParseRegexNode returned an incorrect root: %s", node)
+	}
+	repetition := node.Children[0]
+	if repetition.Type != Repetition || repetition.Value != "+" || repetition.Children[0].Type != Group {
+		t.Errorf("ParseRegexNode returned an incorrect repetition: %s", repetition)
+	}
+	if node.Children[1].Type != Repetition || node.Children[1].Children[0].Type != CharClass {
+		t.Errorf("ParseRegexNode returned an incorrect class: %s", node.Children[1])
+	}
+	if node.Children[2].Type != Backreference || node.Children[2].Value != "1" {
+		t.Errorf("ParseRegexNode returned an incorrect backreference: %s", node.Children[2])
+	}
+
+	if _, ok := ParseRegexNode("(a"); ok {
+		t.Errorf("ParseRegexNode accepts an invalid rule.")
+	}
+}
+
+func TestRuleTree_ParseRegexNodeString(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc.")
+	rules := []string{"(ab)*(ba)*", "a.*c", `[a-b\.]+c?`, "(?:a|bc){2,}", "^a{2}b*|c{3}$", `(.)(?!\1)\w`, `\d|\.\W`, "(?=a)..?"}
+	for _, rule := range rules {
+		node, ok := ParseRegexNode(rule)
+		if !ok {
+			t.Fatalf("ParseRegexNode failed on %s.", rule)
+		}
+		printed := node.String()
+		for length := range 4 {
+			candidate, ok := MakeCandidateFirst(alphabet, length)
+			for ok {
+				row := candidate.String()
+				if MatchRule(rule, row) != MatchRule(printed, row) {
+					t.Errorf("The parsed tree %s of %s differs on %q.", printed, rule, row)
+				}
+				candidate, ok = candidate.IncrementCandidate()
+			}
+		}
+	}
+}
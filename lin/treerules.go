@@ -2,7 +2,9 @@ package lin
 
 import (
 	"crossmatcher/collection"
+	"math"
 	"math/rand"
+	"regexp"
 	"slices"
 )
 
@@ -13,22 +15,93 @@ const (
 	Concatenation
 	Alternation
 	Repetition
+	CharClass
+	AnyChar
+	Anchor
+	Group
+	Backreference
+	Lookahead
 )
 
+// RegexNode is a node of a rule tree.
+// Literal holds a single character, CharClass its class like [a-c] and AnyChar, Anchor and Backreference their syntax.
+// Repetition holds its quantifier like +, *, ? or {m,n}, Group is "" if capturing and "?:" otherwise,
+// Lookahead is "=" or "!" for the negated form.
 type RegexNode struct {
 	Type     RegexNodeType
 	Value    string
 	Children []RegexNode
 }
 
+// String prints the rule of a tree with only the parentheses needed by the operator precedence.
+// Trees with backreferences get non-capturing parentheses, so the group numbers stay the same.
 func (node RegexNode) String() string {
+	grouping := "("
+	if node.contains(Backreference) {
+		grouping = "(?:"
+	}
+	return node.format(grouping)
+}
+
+const (
+	precedenceAlternation = iota
+	precedenceConcatenation
+	precedenceRepetition
+	precedenceAtom
+)
+
+// precedence returns how tightly the printed node binds.
+// Anchors and lookaheads are not repeatable, so they bind like a repetition.
+func (node RegexNode) precedence() int {
 	switch node.Type {
 	case Literal:
+		if len([]rune(node.Value)) == 1 {
+			return precedenceAtom
+		}
+		return precedenceConcatenation
+	case Concatenation, Alternation:
+		if len(node.Children) == 1 {
+			return node.Children[0].precedence()
+		}
+		if node.Type == Alternation {
+			return precedenceAlternation
+		}
+		return precedenceConcatenation
+	case Repetition, Anchor, Lookahead:
+		return precedenceRepetition
+	default:
+		return precedenceAtom
+	}
+}
+
+// format prints the node, grouping is the opening parenthesis used where the precedence needs one.
+func (node RegexNode) format(grouping string) string {
+	wrap := func(child RegexNode, precedence int) string {
+		if child.precedence() < precedence {
+			return grouping + child.format(grouping) + ")"
+		}
+		return child.format(grouping)
+	}
+	switch node.Type {
+	case Literal:
+		return regexp.QuoteMeta(node.Value)
+	case CharClass, AnyChar, Anchor:
 		return node.Value
+	case Backreference:
+		return "\\" + node.Value
+	case Group:
+		return "(" + node.Value + node.Children[0].format(grouping) + ")"
+	case Lookahead:
+		return "(?" + node.Value + node.Children[0].format(grouping) + ")"
 	case Concatenation:
 		ret := ""
-		for _, child := range node.Children {
-			ret += child.String()
+		for i, child := range node.Children {
+			part := wrap(child, precedenceConcatenation)
+			// a digit directly after a backreference would change its group number
+			if i > 0 && node.Children[i-1].endsWithBackreference() && part != "" && part[0] >= '0' && part[0] <= '9' {
+				part = grouping + part + ")"
+			}
+			ret += part
 		}
 		return ret
 	case Alternation:
@@ -37,16 +110,42 @@ func (node RegexNode) String() string {
 			if i > 0 {
 				ret += "|"
 			}
-			ret += child.String()
+			ret += child.format(grouping)
 		}
 		return ret
 	case Repetition:
-		return "(" + node.Children[0].String() + ")" + node.Value
+		return wrap(node.Children[0], precedenceAtom) + node.Value
 	default:
 		return ""
 	}
 }
 
+// contains checks, whether the tree has a node of the given type.
+func (node RegexNode) contains(nodeType RegexNodeType) bool {
+	if node.Type == nodeType {
+		return true
+	}
+	for _, child := range node.Children {
+		if child.contains(nodeType) {
+			return true
+		}
+	}
+	return false
+}
+
+func (node RegexNode) endsWithBackreference() bool {
+	switch node.Type {
+	case Backreference:
+		return true
+	case Concatenation:
+		return len(node.Children) > 0 && node.Children[len(node.Children)-1].endsWithBackreference()
+	case Alternation:
+		return len(node.Children) == 1 && node.Children[0].endsWithBackreference()
+	default:
+		return false
+	}
+}
+
 func (node RegexNode) DeepCopy() RegexNode {
 	if node.Type == Literal {
 		return RegexNode{Type: Literal, Value: node.Value}
@@ -84,19 +183,24 @@ func (node RegexNode) SimplifyAlternations() RegexNode {
 }
 
 func (node RegexNode) RandomizeAlternations() RegexNode {
+	return node.RandomizeAlternationsRand(newRand())
+}
+
+// RandomizeAlternationsRand does the same as RandomizeAlternations, but draws the order from rng
+func (node RegexNode) RandomizeAlternationsRand(rng *rand.Rand) RegexNode {
 	if node.Type == Literal {
 		return RegexNode{Type: Literal, Value: node.Value}
 	}
 	newNode := RegexNode{Type: node.Type, Value: node.Value}
 	if node.Type == Alternation {
 		for _, child := range node.Children {
-			randomizedChild := child.RandomizeAlternations()
-			index := rand.Intn(len(newNode.Children) + 1)
+			randomizedChild := child.RandomizeAlternationsRand(rng)
+			index := rng.Intn(len(newNode.Children) + 1)
 			newNode.Children = slices.Insert(newNode.Children, index, randomizedChild)
 		}
 	} else {
 		for _, child := range node.Children {
-			newNode.Children = append(newNode.Children, child.RandomizeAlternations())
+			newNode.Children = append(newNode.Children, child.RandomizeAlternationsRand(rng))
 		}
 	}
 	return newNode
@@ -115,6 +219,12 @@ func MakeRegexNode(value string) RegexNode {
 // consisting of only a concatenation of characters from the alphabet randomly into blocks
 // currently biased because the split starts from the front
 func (node RegexNode) SeparateIntoBlocks() RegexNode {
+	return node.SeparateIntoBlocksWith(BlockProbabilityAcc(len(node.Children), 0), newRand())
+}
+
+// SeparateIntoBlocksWith does the same as SeparateIntoBlocks, but draws the block sizes with rng from the given
+// accumulated probabilities
+func (node RegexNode) SeparateIntoBlocksWith(blockProbabilityAcc []float64, rng *rand.Rand) RegexNode {
 	if node.Type != Concatenation {
 		return node
 	}
@@ -126,7 +236,7 @@ func (node RegexNode) SeparateIntoBlocks() RegexNode {
 	index := 0
 
 	for index < len(childrenOld) {
-		end := index + getBlockLength()
+		end := index + getBlockLength(blockProbabilityAcc, rng)
 		if end > len(childrenOld) {
 			end = len(childrenOld)
 		}
@@ -164,11 +274,16 @@ func (node RegexNode) WithRepetitionSubgroups() RegexNode {
 
 // MergeRandomBlocks Merges the Alternation-Grandchildren of Repetition-Children
 func (node RegexNode) MergeRandomBlocks() RegexNode {
+	return node.MergeRandomBlocksRand(newRand())
+}
+
+// MergeRandomBlocksRand does the same as MergeRandomBlocks, but draws the blocks from rng
+func (node RegexNode) MergeRandomBlocksRand(rng *rand.Rand) RegexNode {
 	if len(node.Children) <= 1 {
 		return node
 	}
 	ret := node.DeepCopy()
-	leftIndex := rand.Intn(len(ret.Children) - 1)
+	leftIndex := rng.Intn(len(ret.Children) - 1)
 	rightIndex := leftIndex + 1
 	leftAlternation := &ret.Children[leftIndex].Children[0].Children
 	rightAlternation := &ret.Children[rightIndex].Children[0].Children
@@ -180,21 +295,26 @@ func (node RegexNode) MergeRandomBlocks() RegexNode {
 }
 
 func (node RegexNode) ExtendRandomAlternationElement(alphabet collection.Alphabet) RegexNode {
+	return node.ExtendRandomAlternationElementRand(alphabet, newRand())
+}
+
+// ExtendRandomAlternationElementRand does the same as ExtendRandomAlternationElement, but draws the element,
+// the character and its position from rng
+func (node RegexNode) ExtendRandomAlternationElementRand(alphabet collection.Alphabet, rng *rand.Rand) RegexNode {
 	ret := node.DeepCopy()
 	alphabetRunes := []rune(alphabet.String())
 
-	groupIndex := rand.Intn(len(ret.Children))
-	elementIndex := rand.Intn(len(ret.Children[groupIndex].Children[0].Children))
+	groupIndex := rng.Intn(len(ret.Children))
+	elementIndex := rng.Intn(len(ret.Children[groupIndex].Children[0].Children))
 	alternationElement := ret.Children[groupIndex].Children[0].Children[elementIndex].DeepCopy()
 
-	// shortening single character makes no sense
-	// shortening double character probably makes no fun
-	if len(alternationElement.Children) == len(getBlockProbabilityAcc()) {
+	// extending longer elements makes the blocks too long to keep track of
+	if len(alternationElement.Children) >= maxAlternationElementLength {
 		return node
 	}
 
-	char := alphabetRunes[rand.Intn(len(alphabetRunes))]
-	leftIndex := rand.Intn(len(alternationElement.Children) + 1)
+	char := alphabetRunes[rng.Intn(len(alphabetRunes))]
+	leftIndex := rng.Intn(len(alternationElement.Children) + 1)
 
 	charNode := RegexNode{Type: Literal, Value: string(char)}
 	alternationElement.Children = slices.Insert(alternationElement.Children, leftIndex, charNode)
@@ -204,10 +324,16 @@ func (node RegexNode) ExtendRandomAlternationElement(alphabet collection.Alphabe
 }
 
 func (node RegexNode) ShortenRandomAlternationElement() RegexNode {
+	return node.ShortenRandomAlternationElementRand(newRand())
+}
+
+// ShortenRandomAlternationElementRand does the same as ShortenRandomAlternationElement, but draws the element
+// and the removed position from rng
+func (node RegexNode) ShortenRandomAlternationElementRand(rng *rand.Rand) RegexNode {
 	ret := node.DeepCopy()
 
-	groupIndex := rand.Intn(len(ret.Children))
-	elementIndex := rand.Intn(len(ret.Children[groupIndex].Children[0].Children))
+	groupIndex := rng.Intn(len(ret.Children))
+	elementIndex := rng.Intn(len(ret.Children[groupIndex].Children[0].Children))
 	alternationElement := ret.Children[groupIndex].Children[0].Children[elementIndex].DeepCopy()
 
 	// shortening single character makes no sense
@@ -216,7 +342,7 @@ func (node RegexNode) ShortenRandomAlternationElement() RegexNode {
 		return node
 	}
 
-	leftIndex := rand.Intn(len(alternationElement.Children))
+	leftIndex := rng.Intn(len(alternationElement.Children))
 
 	alternationElement.Children = slices.Delete(alternationElement.Children, leftIndex, leftIndex+1)
 	ret.Children[groupIndex].Children[0].Children = append(ret.Children[groupIndex].Children[0].Children, alternationElement)
@@ -224,20 +350,46 @@ func (node RegexNode) ShortenRandomAlternationElement() RegexNode {
 	return ret
 }
 
-// getBlockProbabilityAcc returns the accumulated probabilities of blocks of different sizes
-// TODO: At some point in the future, this should depend on the size of the crossword
-func getBlockProbabilityAcc() []float64 {
-	return []float64{0.0, 0.05, 0.35, 0.8}
+// maxAlternationElementLength is the length up to which ExtendRandomAlternationElement extends elements
+const maxAlternationElementLength = 4
+
+// blockWeights are the relative frequencies of blocks of sizes 1 to 5
+var blockWeights = []float64{1, 6, 9, 4, 2}
+
+// BlockProbabilityAcc returns the accumulated probabilities of blocks of different sizes, starting with size 0,
+// for a rule of the given length. Longer rules allow longer blocks, so that short rules still get several blocks.
+// A positive tilt favours longer blocks and a negative tilt shorter ones, each unit doubling the ratio
+// between neighbouring sizes.
+func BlockProbabilityAcc(length int, tilt float64) []float64 {
+	maxSize := min(max((length+1)/2, 2), len(blockWeights))
+	weights := make([]float64, maxSize)
+	sum := 0.0
+	for i := range weights {
+		weights[i] = blockWeights[i] * math.Pow(2, tilt*float64(i))
+		sum += weights[i]
+	}
+	blockProbabilityAcc := make([]float64, maxSize+1)
+	acc := 0.0
+	for i, weight := range weights {
+		acc += weight
+		blockProbabilityAcc[i+1] = acc / sum
+	}
+	blockProbabilityAcc[maxSize] = 1
+	return blockProbabilityAcc
 }
 
 // getBlockLength gets a random size from the accumulated block lengths
-func getBlockLength() int {
-	blockProbabilityAcc := getBlockProbabilityAcc()
-	randomVal := rand.Float64()
+func getBlockLength(blockProbabilityAcc []float64, rng *rand.Rand) int {
+	randomVal := rng.Float64()
 	for i, acc := range blockProbabilityAcc {
 		if randomVal < acc {
 			return i
 		}
 	}
-	return len(blockProbabilityAcc)
+	return len(blockProbabilityAcc) - 1
+}
+
+// newRand makes a generator for the functions without an explicit one, seeded from the global generator
+func newRand() *rand.Rand {
+	return rand.New(rand.NewSource(rand.Int63()))
 }
@@ -1,6 +1,9 @@
 package lin
 
 import (
+	"math/rand"
+	"regexp"
+	"slices"
 	"strings"
 	"testing"
 )
@@ -41,13 +44,12 @@ func TestRuleTree_Stringer(t *testing.T) {
 func TestRuleTree_WithRepetitionSubgroups(t *testing.T) {
 	base := MakeRegexNode("RegularExpression")
 	actual := base.SeparateIntoBlocks().WithAlternationSubgroups().WithRepetitionSubgroups().String()
-	prefix := "(R"
-	suffix := "n)+"
-	if prefix != actual[:len(prefix)] {
-		t.Errorf("WithRepetitionSubgroups is incorrect. Expected prefix:%s, actual:%s", prefix, actual)
+	// blocks of a single character need no parentheses
+	if !strings.HasPrefix(actual, "(R") && !strings.HasPrefix(actual, "R+") {
+		t.Errorf("WithRepetitionSubgroups is incorrect. Expected prefix:(R or R+, actual:%s", actual)
 	}
-	if suffix != actual[len(actual)-len(suffix):] {
-		t.Errorf("WithRepetitionSubgroups is incorrect. Expected suffix:%s, actual:%s", suffix, actual)
+	if !strings.HasSuffix(actual, "n)+") && !strings.HasSuffix(actual, "n+") {
+		t.Errorf("WithRepetitionSubgroups is incorrect. Expected suffix:n)+ or n+, actual:%s", actual)
 	}
 }
 
@@ -55,14 +57,12 @@ func TestRuleTree_MergeRandomBlocks(t *testing.T) {
 	base := MakeRegexNode("RegularExpression").SeparateIntoBlocks().WithAlternationSubgroups().WithRepetitionSubgroups()
 	goal := base.MergeRandomBlocks()
 	actual := goal.String()
-	prefix := "(R"
-	suffix := "n)+"
 	infix := "|"
-	if prefix != actual[:len(prefix)] {
-		t.Errorf("WithRepetitionSubgroups is incorrect. Expected prefix:%s, actual:%s", prefix, actual)
+	if !strings.HasPrefix(actual, "(R") && !strings.HasPrefix(actual, "R+") {
+		t.Errorf("WithRepetitionSubgroups is incorrect. Expected prefix:(R or R+, actual:%s", actual)
 	}
-	if suffix != actual[len(actual)-len(suffix):] {
-		t.Errorf("WithRepetitionSubgroups is incorrect. Expected suffix:%s, actual:%s", suffix, actual)
+	if !strings.HasSuffix(actual, "n)+") && !strings.HasSuffix(actual, "n+") {
+		t.Errorf("WithRepetitionSubgroups is incorrect. Expected suffix:n)+ or n+, actual:%s", actual)
 	}
 	if strings.Count(actual, infix) != 1 {
 		t.Errorf("WithRepetitionSubgroups is incorrect. Expected one occurrence of :%s, actual:%s", infix, actual)
@@ -93,3 +93,165 @@ func TestRuleTree_SimplifyAlternations(t *testing.T) {
 		t.Errorf("SimplifyAlternations is incorrect. Expected no duplicates, actual:%s", simplified.String())
 	}
 }
+
+func TestRuleTree_StringPrecedence(t *testing.T) {
+	a := RegexNode{Literal, "a", nil}
+	b := RegexNode{Literal, "b", nil}
+	alternation := RegexNode{Alternation, "", []RegexNode{a, b}}
+	concatenation := RegexNode{Concatenation, "", []RegexNode{a, b}}
+	group := RegexNode{Group, "", []RegexNode{a}}
+	backreference := RegexNode{Backreference, "1", nil}
+	one := RegexNode{Literal, "1", nil}
+	for expected, node := range map[string]RegexNode{
+		"(a|b)b":       {Concatenation, "", []RegexNode{alternation, b}},
+		"a|ab":         {Alternation, "", []RegexNode{a, concatenation}},
+		"a*":           {Repetition, "*", []RegexNode{a}},
+		"(ab)+":        {Repetition, "+", []RegexNode{concatenation}},
+		"(a+)?":        {Repetition, "?", []RegexNode{{Repetition, "+", []RegexNode{a}}}},
+		`(a)(?:a|b)\1`: {Concatenation, "", []RegexNode{group, alternation, backreference}},
+		`(a)\1(?:1)`:   {Concatenation, "", []RegexNode{group, backreference, one}},
+		`a\.`:          {Concatenation, "", []RegexNode{a, {Literal, ".", nil}}},
+	} {
+		if actual := node.String(); actual != expected {
+			t.Errorf("String is incorrect. Expected:%s, actual:%s", expected, actual)
+		}
+	}
+}
+
+func TestRuleTree_StringRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	alphabet := []string{"a", "b", "c"}
+	var rows []string
+	for length := range 5 {
+		rows = append(rows, allRows(alphabet, length)...)
+	}
+	for range 300 {
+		node := randomRegexNode(rng, 3)
+		printed := node.String()
+		language := regexLanguage(node, alphabet, 4)
+		parsed, ok := ParseRegexNode(printed)
+		if !ok {
+			t.Fatalf("ParseRegexNode fails on the printed tree %s.", printed)
+		}
+		re := regexp.MustCompile("^(" + printed + ")$")
+		reparsed := regexp.MustCompile("^(" + parsed.String() + ")$")
+		for _, row := range rows {
+			if re.MatchString(row) != language[row] || reparsed.MatchString(row) != language[row] {
+				t.Errorf("String of %#v is incorrect on %q, printed %s.", node, row, printed)
+				break
+			}
+		}
+	}
+}
+
+// randomRegexNode makes a random tree without backreferences, anchors and lookaheads.
+func randomRegexNode(rng *rand.Rand, depth int) RegexNode {
+	leaves := []RegexNode{{Literal, "a", nil}, {Literal, "b", nil}, {CharClass, "[bc]", nil}, {CharClass, "[^a]", nil}, {AnyChar, ".", nil}}
+	if depth == 0 || rng.Intn(3) == 0 {
+		return leaves[rng.Intn(len(leaves))]
+	}
+	switch rng.Intn(4) {
+	case 0:
+		node := RegexNode{Type: Concatenation}
+		for range rng.Intn(4) {
+			node.Children = append(node.Children, randomRegexNode(rng, depth-1))
+		}
+		return node
+	case 1:
+		node := RegexNode{Type: Alternation}
+		for range 1 + rng.Intn(3) {
+			node.Children = append(node.Children, randomRegexNode(rng, depth-1))
+		}
+		return node
+	case 2:
+		values := []string{"*", "+", "?", "{2}", "{1,2}", "{0,}"}
+		return RegexNode{Repetition, values[rng.Intn(len(values))], []RegexNode{randomRegexNode(rng, depth-1)}}
+	default:
+		return RegexNode{Group, []string{"", "?:"}[rng.Intn(2)], []RegexNode{randomRegexNode(rng, depth-1)}}
+	}
+}
+
+// regexLanguage returns all rows of at most maxLen characters described by a tree.
+func regexLanguage(node RegexNode, alphabet []string, maxLen int) map[string]bool {
+	language := make(map[string]bool)
+	switch node.Type {
+	case Literal, CharClass, AnyChar:
+		for _, char := range alphabet {
+			if regexp.MustCompile("^" + node.String() + "$").MatchString(char) {
+				language[char] = true
+			}
+		}
+	case Concatenation:
+		language[""] = true
+		for _, child := range node.Children {
+			language = concatLanguages(language, regexLanguage(child, alphabet, maxLen), maxLen)
+		}
+	case Alternation:
+		for _, child := range node.Children {
+			for row := range regexLanguage(child, alphabet, maxLen) {
+				language[row] = true
+			}
+		}
+	case Group:
+		return regexLanguage(node.Children[0], alphabet, maxLen)
+	case Repetition:
+		minCount, maxCount := repetitionBounds(node.Value)
+		child := regexLanguage(node.Children[0], alphabet, maxLen)
+		power := map[string]bool{"": true}
+		for count := 0; maxCount == -1 || count <= maxCount; count++ {
+			if count >= minCount {
+				for row := range power {
+					language[row] = true
+				}
+			}
+			if count > maxLen+minCount {
+				break
+			}
+			power = concatLanguages(power, child, maxLen)
+		}
+	}
+	return language
+}
+
+func concatLanguages(left map[string]bool, right map[string]bool, maxLen int) map[string]bool {
+	language := make(map[string]bool)
+	for l := range left {
+		for r := range right {
+			if len(l)+len(r) <= maxLen {
+				language[l+r] = true
+			}
+		}
+	}
+	return language
+}
+
+func allRows(alphabet []string, length int) []string {
+	rows := []string{""}
+	for range length {
+		var next []string
+		for _, row := range rows {
+			for _, char := range alphabet {
+				next = append(next, row+char)
+			}
+		}
+		rows = next
+	}
+	return rows
+}
+
+func TestBlockProbabilityAcc(t *testing.T) {
+	for _, length := range []int{1, 4, 9, 30} {
+		for _, tilt := range []float64{-2, 0, 1.5} {
+			acc := BlockProbabilityAcc(length, tilt)
+			if acc[0] != 0 || acc[len(acc)-1] != 1 || !slices.IsSorted(acc) {
+				t.Errorf("BlockProbabilityAcc(%d, %v) is no accumulated distribution: %v", length, tilt, acc)
+			}
+		}
+	}
+	if short, long := BlockProbabilityAcc(4, 0), BlockProbabilityAcc(30, 0); len(short) >= len(long) {
+		t.Errorf("BlockProbabilityAcc allows no longer blocks on longer rules: %v, %v", short, long)
+	}
+	if shorter, longer := BlockProbabilityAcc(9, -1), BlockProbabilityAcc(9, 1); shorter[1] <= longer[1] {
+		t.Errorf("BlockProbabilityAcc does not favour shorter blocks on a negative tilt: %v, %v", shorter, longer)
+	}
+}
@@ -0,0 +1,358 @@
+package lin
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Simplify returns a tree that matches the same rows of the given length with less redundancy.
+// Branches that never fit the length are removed, groups are dropped, duplicate alternatives merged,
+// common prefixes and suffixes factored out and single characters of alternations joined into classes.
+// Trees with backreferences depend on their groups and are returned unchanged.
+func (node RegexNode) Simplify(length int) RegexNode {
+	if node.contains(Backreference) || length < 0 {
+		return node.DeepCopy()
+	}
+	allowed := make([]bool, length+1)
+	allowed[length] = true
+	return node.pruneLengths(allowed).simplifyStructure()
+}
+
+// lengths returns which row lengths up to maxLen the node can match.
+func (node RegexNode) lengths(maxLen int) []bool {
+	possible := make([]bool, maxLen+1)
+	switch node.Type {
+	case Literal:
+		if size := len([]rune(node.Value)); size <= maxLen {
+			possible[size] = true
+		}
+	case CharClass, AnyChar:
+		if maxLen >= 1 {
+			possible[1] = true
+		}
+	case Anchor, Lookahead:
+		possible[0] = true
+	case Group:
+		return node.Children[0].lengths(maxLen)
+	case Concatenation:
+		possible[0] = true
+		for _, child := range node.Children {
+			possible = sumLengths(possible, child.lengths(maxLen))
+		}
+	case Alternation:
+		for _, child := range node.Children {
+			for size, ok := range child.lengths(maxLen) {
+				possible[size] = possible[size] || ok
+			}
+		}
+	case Repetition:
+		minCount, maxCount := repetitionBounds(node.Value)
+		child := node.Children[0].lengths(maxLen)
+		power := make([]bool, maxLen+1)
+		power[0] = true
+		for count := 0; maxCount == -1 || count <= maxCount; count++ {
+			if count >= minCount {
+				for size, ok := range power {
+					possible[size] = possible[size] || ok
+				}
+			}
+			// after maxLen+1 more repetitions every reachable length has been seen
+			if count > minCount+maxLen {
+				break
+			}
+			power = sumLengths(power, child)
+		}
+	default:
+		for size := range possible {
+			possible[size] = true
+		}
+	}
+	return possible
+}
+
+// sumLengths returns the lengths of a concatenation of two parts, up to the common maximum.
+func sumLengths(left []bool, right []bool) []bool {
+	sum := make([]bool, len(left))
+	for i, leftOk := range left {
+		for j, rightOk := range right {
+			if leftOk && rightOk && i+j < len(sum) {
+				sum[i+j] = true
+			}
+		}
+	}
+	return sum
+}
+
+// repetitionBounds reads the minimal and maximal count of a quantifier, -1 for unbounded.
+func repetitionBounds(value string) (int, int) {
+	switch value {
+	case "*":
+		return 0, -1
+	case "+":
+		return 1, -1
+	case "?":
+		return 0, 1
+	}
+	bounds := strings.Split(strings.Trim(value, "{}"), ",")
+	minCount, _ := strconv.Atoi(bounds[0])
+	if len(bounds) == 1 {
+		return minCount, minCount
+	}
+	if bounds[1] == "" {
+		return minCount, -1
+	}
+	maxCount, _ := strconv.Atoi(bounds[1])
+	return minCount, maxCount
+}
+
+// pruneLengths removes alternatives that can not reach any of the allowed lengths.
+// An alternation without any fitting alternative is kept, since it can not match anyway.
+func (node RegexNode) pruneLengths(allowed []bool) RegexNode {
+	maxLen := len(allowed) - 1
+	ret := RegexNode{Type: node.Type, Value: node.Value}
+	switch node.Type {
+	case Alternation:
+		for _, child := range node.Children {
+			if intersects(child.lengths(maxLen), allowed) {
+				ret.Children = append(ret.Children, child.pruneLengths(allowed))
+			}
+		}
+		if len(ret.Children) == 0 {
+			return node.DeepCopy()
+		}
+	case Concatenation:
+		childLengths := make([][]bool, len(node.Children))
+		for i, child := range node.Children {
+			childLengths[i] = child.lengths(maxLen)
+		}
+		for i, child := range node.Children {
+			others := make([]bool, maxLen+1)
+			others[0] = true
+			for j := range node.Children {
+				if j != i {
+					others = sumLengths(others, childLengths[j])
+				}
+			}
+			childAllowed := make([]bool, maxLen+1)
+			for size := range childAllowed {
+				for otherSize, ok := range others {
+					if ok && size+otherSize <= maxLen && allowed[size+otherSize] {
+						childAllowed[size] = true
+					}
+				}
+			}
+			ret.Children = append(ret.Children, child.pruneLengths(childAllowed))
+		}
+	case Group:
+		ret.Children = []RegexNode{node.Children[0].pruneLengths(allowed)}
+	case Repetition:
+		// each repetition may take any length up to the longest allowed one
+		childAllowed := make([]bool, maxLen+1)
+		for size := range childAllowed {
+			childAllowed[size] = slices.Contains(allowed[size:], true)
+		}
+		ret.Children = []RegexNode{node.Children[0].pruneLengths(childAllowed)}
+	default:
+		return node.DeepCopy()
+	}
+	return ret
+}
+
+func intersects(left []bool, right []bool) bool {
+	for i := range min(len(left), len(right)) {
+		if left[i] && right[i] {
+			return true
+		}
+	}
+	return false
+}
+
+// simplifyStructure rewrites the tree bottom up without changing its language.
+func (node RegexNode) simplifyStructure() RegexNode {
+	var children []RegexNode
+	for _, child := range node.Children {
+		children = append(children, child.simplifyStructure())
+	}
+	switch node.Type {
+	case Group:
+		return children[0]
+	case Concatenation:
+		var flat []RegexNode
+		for _, child := range children {
+			if child.Type == Concatenation {
+				flat = append(flat, child.Children...)
+			} else {
+				flat = append(flat, child)
+			}
+		}
+		if len(flat) == 1 {
+			return flat[0]
+		}
+		return RegexNode{Type: Concatenation, Children: flat}
+	case Alternation:
+		return simplifyAlternatives(children)
+	default:
+		return RegexNode{Type: node.Type, Value: node.Value, Children: children}
+	}
+}
+
+// simplifyAlternatives joins simplified alternatives into a single node.
+func simplifyAlternatives(children []RegexNode) RegexNode {
+	var flat []RegexNode
+	seen := make(map[string]bool)
+	for _, child := range children {
+		alternatives := []RegexNode{child}
+		if child.Type == Alternation {
+			alternatives = child.Children
+		}
+		for _, alternative := range alternatives {
+			key := alternative.String()
+			if !seen[key] {
+				seen[key] = true
+				flat = append(flat, alternative)
+			}
+		}
+	}
+	flat = factorAlternatives(flat, false)
+	flat = factorAlternatives(flat, true)
+	flat = joinCharacterAlternatives(flat)
+
+	optional := false
+	var nonEmpty []RegexNode
+	for _, alternative := range flat {
+		if alternative.Type == Concatenation && len(alternative.Children) == 0 {
+			optional = true
+		} else {
+			nonEmpty = append(nonEmpty, alternative)
+		}
+	}
+	if optional && len(nonEmpty) > 0 {
+		inner := nonEmpty[0]
+		if len(nonEmpty) > 1 {
+			inner = RegexNode{Type: Alternation, Children: nonEmpty}
+		}
+		return RegexNode{Type: Repetition, Value: "?", Children: []RegexNode{inner}}
+	}
+	if len(flat) == 1 {
+		return flat[0]
+	}
+	return RegexNode{Type: Alternation, Children: flat}
+}
+
+// sequence returns the parts of a node that are matched one after another.
+func (node RegexNode) sequence() []RegexNode {
+	if node.Type == Concatenation {
+		return node.Children
+	}
+	return []RegexNode{node}
+}
+
+func makeSequence(parts []RegexNode) RegexNode {
+	if len(parts) == 1 {
+		return parts[0]
+	}
+	return RegexNode{Type: Concatenation, Children: slices.Clone(parts)}
+}
+
+// factorAlternatives factors the common first (or last, if fromEnd) part out of alternatives sharing it.
+// The factored alternative takes the place of the first alternative of its kind.
+func factorAlternatives(alternatives []RegexNode, fromEnd bool) []RegexNode {
+	edge := func(node RegexNode) RegexNode {
+		parts := node.sequence()
+		if fromEnd {
+			return parts[len(parts)-1]
+		}
+		return parts[0]
+	}
+	rest := func(node RegexNode) RegexNode {
+		parts := node.sequence()
+		if fromEnd {
+			return makeSequence(parts[:len(parts)-1])
+		}
+		return makeSequence(parts[1:])
+	}
+
+	members := make(map[string][]int)
+	for i, alternative := range alternatives {
+		if len(alternative.sequence()) > 0 {
+			key := edge(alternative).String()
+			members[key] = append(members[key], i)
+		}
+	}
+
+	var ret []RegexNode
+	for i, alternative := range alternatives {
+		if len(alternative.sequence()) == 0 {
+			ret = append(ret, alternative)
+			continue
+		}
+		group := members[edge(alternative).String()]
+		if len(group) == 1 {
+			ret = append(ret, alternative)
+			continue
+		}
+		if group[0] != i {
+			continue
+		}
+		var rests []RegexNode
+		for _, member := range group {
+			rests = append(rests, rest(alternatives[member]))
+		}
+		restParts := simplifyAlternatives(rests).sequence()
+		if fromEnd {
+			ret = append(ret, makeSequence(append(restParts, edge(alternative))))
+		} else {
+			ret = append(ret, makeSequence(append([]RegexNode{edge(alternative)}, restParts...)))
+		}
+	}
+	return ret
+}
+
+// joinCharacterAlternatives joins single characters and classes of an alternation into one class.
+// The class takes the place of the first joined alternative.
+func joinCharacterAlternatives(alternatives []RegexNode) []RegexNode {
+	var joinable []int
+	for i, alternative := range alternatives {
+		if alternative.isSingleCharacter() || alternative.isPositiveClass() {
+			joinable = append(joinable, i)
+		}
+	}
+	if len(joinable) < 2 {
+		return alternatives
+	}
+
+	var chars []rune
+	classes := ""
+	for _, i := range joinable {
+		if alternatives[i].Type == Literal {
+			chars = append(chars, []rune(alternatives[i].Value)[0])
+		} else {
+			classes += strings.TrimSuffix(strings.TrimPrefix(alternatives[i].Value, "["), "]")
+		}
+	}
+	slices.Sort(chars)
+	chars = slices.Compact(chars)
+	class := ""
+	for _, char := range chars {
+		class += classChar(char)
+	}
+
+	var ret []RegexNode
+	for i, alternative := range alternatives {
+		if i == joinable[0] {
+			ret = append(ret, RegexNode{Type: CharClass, Value: "[" + class + classes + "]"})
+		} else if !slices.Contains(joinable, i) {
+			ret = append(ret, alternative)
+		}
+	}
+	return ret
+}
+
+func (node RegexNode) isSingleCharacter() bool {
+	return node.Type == Literal && len([]rune(node.Value)) == 1
+}
+
+func (node RegexNode) isPositiveClass() bool {
+	return node.Type == CharClass && strings.HasPrefix(node.Value, "[") && !strings.HasPrefix(node.Value, "[^")
+}
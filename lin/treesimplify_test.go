@@ -0,0 +1,52 @@
+package lin
+
+import (
+	"math/rand"
+	"regexp"
+	"testing"
+)
+
+func TestRuleTree_Simplify(t *testing.T) {
+	for _, test := range []struct {
+		rule     string
+		length   int
+		expected string
+	}{
+		{"(ab|ab|a)+", 4, "(ab?)+"},
+		{"a|b|c", 1, "[abc]"},
+		{"abc|abd", 3, "ab[cd]"},
+		{"xa|yb|a", 2, "xa|yb"},
+		{"(abc|a)b", 2, "ab"},
+		{"(a|[bc])d|ed", 2, "[eabc]d"},
+		{"((a))", 1, "a"},
+	} {
+		node, _ := ParseRegexNode(test.rule)
+		if actual := node.Simplify(test.length).String(); actual != test.expected {
+			t.Errorf("Simplify of %s with length %d is incorrect. Expected:%s, actual:%s", test.rule, test.length, test.expected, actual)
+		}
+	}
+
+	node, _ := ParseRegexNode(`(a|a)\1`)
+	if actual := node.Simplify(2).String(); actual != `(a|a)\1` {
+		t.Errorf("Simplify changes a rule with a backreference to %s", actual)
+	}
+}
+
+func TestRuleTree_SimplifyKeepsLanguage(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	alphabet := []string{"a", "b", "c"}
+	for range 300 {
+		node := randomRegexNode(rng, 4)
+		re := regexp.MustCompile("^(" + node.String() + ")$")
+		for length := range 5 {
+			simplified := node.Simplify(length)
+			simplifiedRe := regexp.MustCompile("^(" + simplified.String() + ")$")
+			for _, row := range allRows(alphabet, length) {
+				if re.MatchString(row) != simplifiedRe.MatchString(row) {
+					t.Errorf("Simplify of %s with length %d to %s differs on %q.", node, length, simplified, row)
+					break
+				}
+			}
+		}
+	}
+}
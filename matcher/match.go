@@ -0,0 +1,238 @@
+package matcher
+
+import (
+	"iter"
+	"slices"
+)
+
+// Pattern is a compiled rule for the backtracking matcher.
+// In contrast to Go regexp it supports backreferences and lookaheads, but it takes exponential time in the worst case.
+type Pattern struct {
+	root   *Node
+	groups int
+}
+
+// Compile parses a rule into a pattern matching whole lines.
+func Compile(rule string) (Pattern, error) {
+	root, groups, err := Parse(rule)
+	if err != nil {
+		return Pattern{}, err
+	}
+	return Pattern{root, groups}, nil
+}
+
+// Match checks, whether the pattern matches the whole string.
+func (p Pattern) Match(s string) bool {
+	var cells [][]rune
+	for _, char := range s {
+		cells = append(cells, []rune{char})
+	}
+	for range p.Fill(cells) {
+		return true
+	}
+	return false
+}
+
+// Fill enumerates all distinct strings matched by the pattern that take one of the given runes at each position.
+// Positions are bound lazily while matching, so runes are only tried where the pattern reaches them.
+func (p Pattern) Fill(cells [][]rune) iter.Seq[[]rune] {
+	return func(yield func([]rune) bool) {
+		m := &machine{
+			cells:    cells,
+			bound:    make([]rune, len(cells)),
+			captures: make([]span, p.groups+1),
+		}
+		for i := range m.bound {
+			m.bound[i] = unbound
+		}
+		for i := range m.captures {
+			m.captures[i] = span{-1, -1}
+		}
+		seen := make(map[string]bool)
+		m.match(p.root, 0, func(pos int) bool {
+			if pos != len(cells) {
+				return false
+			}
+			key := string(m.bound)
+			if seen[key] {
+				return false
+			}
+			seen[key] = true
+			return !yield([]rune(key))
+		})
+	}
+}
+
+const unbound rune = -1
+
+type span struct {
+	start int
+	end   int
+}
+
+// machine holds the state of a backtracking match.
+// Continuations get the position reached and return true to stop the whole search.
+// Every step undoes its bindings and captures before returning, in both cases.
+type machine struct {
+	cells    [][]rune
+	bound    []rune
+	captures []span
+}
+
+func (m *machine) match(node *Node, pos int, k func(int) bool) bool {
+	switch node.Type {
+	case Char, Class, Any:
+		return m.matchRune(node, pos, k)
+	case Concat:
+		return m.matchConcat(node.Children, pos, k)
+	case Alternate:
+		for _, child := range node.Children {
+			if m.match(child, pos, k) {
+				return true
+			}
+		}
+		return false
+	case Repeat:
+		return m.matchRepeat(node, 0, pos, k)
+	case Group:
+		if node.Index == 0 {
+			return m.match(node.Children[0], pos, k)
+		}
+		return m.match(node.Children[0], pos, func(end int) bool {
+			old := m.captures[node.Index]
+			m.captures[node.Index] = span{pos, end}
+			stop := k(end)
+			m.captures[node.Index] = old
+			return stop
+		})
+	case Backref:
+		return m.matchBackref(node.Index, pos, k)
+	case Lookahead:
+		if node.Negated {
+			return m.matchNegativeLookahead(node.Children[0], pos, k)
+		}
+		return m.match(node.Children[0], pos, func(int) bool {
+			return k(pos)
+		})
+	case Begin:
+		return pos == 0 && k(pos)
+	case End:
+		return pos == len(m.cells) && k(pos)
+	}
+	return false
+}
+
+func (m *machine) matchRune(node *Node, pos int, k func(int) bool) bool {
+	if pos >= len(m.cells) {
+		return false
+	}
+	if m.bound[pos] != unbound {
+		return node.MatchRune(m.bound[pos]) && k(pos+1)
+	}
+	for _, char := range m.cells[pos] {
+		if !node.MatchRune(char) {
+			continue
+		}
+		m.bound[pos] = char
+		stop := k(pos + 1)
+		m.bound[pos] = unbound
+		if stop {
+			return true
+		}
+	}
+	return false
+}
+
+func (m *machine) matchConcat(children []*Node, pos int, k func(int) bool) bool {
+	if len(children) == 0 {
+		return k(pos)
+	}
+	return m.match(children[0], pos, func(next int) bool {
+		return m.matchConcat(children[1:], next, k)
+	})
+}
+
+// matchRepeat tries more repetitions first. Empty repetitions are only allowed to reach the minimum count.
+func (m *machine) matchRepeat(node *Node, count int, pos int, k func(int) bool) bool {
+	if node.Max == -1 || count < node.Max {
+		stop := m.match(node.Children[0], pos, func(next int) bool {
+			if next == pos && count >= node.Min {
+				return false
+			}
+			return m.matchRepeat(node, count+1, next, k)
+		})
+		if stop {
+			return true
+		}
+	}
+	return count >= node.Min && k(pos)
+}
+
+// matchBackref matches the text of a group. A group that has not captured anything matches the empty string.
+func (m *machine) matchBackref(index int, pos int, k func(int) bool) bool {
+	captured := m.captures[index]
+	if captured.start == -1 {
+		return k(pos)
+	}
+	length := captured.end - captured.start
+	if pos+length > len(m.cells) {
+		return false
+	}
+	var newlyBound []int
+	unbind := func() {
+		for _, i := range newlyBound {
+			m.bound[i] = unbound
+		}
+	}
+	for i := range length {
+		char := m.bound[captured.start+i]
+		target := pos + i
+		if m.bound[target] != unbound {
+			if m.bound[target] != char {
+				unbind()
+				return false
+			}
+			continue
+		}
+		if !slices.Contains(m.cells[target], char) {
+			unbind()
+			return false
+		}
+		m.bound[target] = char
+		newlyBound = append(newlyBound, target)
+	}
+	stop := k(pos + length)
+	unbind()
+	return stop
+}
+
+// matchNegativeLookahead succeeds if the child can not match with any binding of the free positions.
+// If the child matches only by binding free positions, the first such position is split on its runes.
+func (m *machine) matchNegativeLookahead(child *Node, pos int, k func(int) bool) bool {
+	before := append([]rune(nil), m.bound...)
+	split := -1
+	matched := m.match(child, pos, func(int) bool {
+		for i, char := range m.bound {
+			if char != before[i] {
+				split = i
+				break
+			}
+		}
+		return true
+	})
+	if !matched {
+		return k(pos)
+	}
+	if split == -1 {
+		return false
+	}
+	for _, char := range m.cells[split] {
+		m.bound[split] = char
+		stop := m.matchNegativeLookahead(child, pos, k)
+		m.bound[split] = unbound
+		if stop {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,88 @@
+package matcher
+
+import (
+	"regexp"
+	"slices"
+	"testing"
+)
+
+func TestPattern_Match(t *testing.T) {
+	for rule, rows := range map[string]map[string]bool{
+		`(a|b)\1`:       {"aa": true, "bb": true, "ab": false, "a": false},
+		`(ab*)c\1`:      {"abcab": true, "aca": true, "abbcabb": true, "abcabb": false},
+		`(?=a)..`:       {"ab": true, "ba": false},
+		`(?!ab).*`:      {"ab": false, "abc": false, "ba": true, "": true},
+		`(?:a(b)|c)d\1`: {"abdb": true, "cd": true, "cdb": false},
+		`[^ab]{2,}?\w`:  {"ccc": true, "cc": false, "cac": false},
+		`(.)(?!\1).`:    {"ab": true, "aa": false},
+	} {
+		pattern, err := Compile(rule)
+		if err != nil {
+			t.Fatalf("Compile failed on %s: %v", rule, err)
+		}
+		for row, expected := range rows {
+			if pattern.Match(row) != expected {
+				t.Errorf("Match of %s is incorrect on %q. Expected %t.", rule, row, expected)
+			}
+		}
+	}
+}
+
+func TestPattern_FillMatchesRegexp(t *testing.T) {
+	rules := []string{"(ab)*(ba)*", "a.*c", "[ab]+c?", "(a|bc)+", "a{2}b*|c{3,}", "^$", ".*(aa|cc).*", "(a*)*b", "[^a]?b+?"}
+	alphabet := []rune("abc")
+	for _, rule := range rules {
+		pattern, err := Compile(rule)
+		if err != nil {
+			t.Fatalf("Compile failed on %s: %v", rule, err)
+		}
+		re := regexp.MustCompile("^(" + rule + ")$")
+		for length := range 5 {
+			cells := make([][]rune, length)
+			for i := range cells {
+				cells[i] = alphabet
+			}
+			var actual []string
+			for row := range pattern.Fill(cells) {
+				actual = append(actual, string(row))
+			}
+			var expected []string
+			for _, row := range allStrings(alphabet, length) {
+				if re.MatchString(row) {
+					expected = append(expected, row)
+				}
+			}
+			slices.Sort(actual)
+			if !slices.Equal(actual, expected) {
+				t.Errorf("Fill of %s with length %d is incorrect. Expected %v, got %v.", rule, length, expected, actual)
+			}
+		}
+	}
+}
+
+func TestPattern_FillBackreference(t *testing.T) {
+	pattern, _ := Compile(`(.)(?!\1)(.)\2\1`)
+	cells := [][]rune{[]rune("ab"), []rune("ab"), []rune("ab"), []rune("ab")}
+	var actual []string
+	for row := range pattern.Fill(cells) {
+		actual = append(actual, string(row))
+	}
+	slices.Sort(actual)
+	if expected := []string{"abba", "baab"}; !slices.Equal(actual, expected) {
+		t.Errorf("Fill is incorrect. Expected %v, got %v.", expected, actual)
+	}
+}
+
+func allStrings(alphabet []rune, length int) []string {
+	rows := []string{""}
+	for range length {
+		var next []string
+		for _, row := range rows {
+			for _, char := range alphabet {
+				next = append(next, row+string(char))
+			}
+		}
+		rows = next
+	}
+	return rows
+}
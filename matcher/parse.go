@@ -0,0 +1,398 @@
+package matcher
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"unicode/utf8"
+)
+
+type NodeType int
+
+const (
+	Char NodeType = iota
+	Class
+	Any
+	Concat
+	Alternate
+	Repeat
+	Group
+	Backref
+	Lookahead
+	Begin
+	End
+)
+
+// Node is a node of the syntax tree of a rule.
+// Char matches Runes[0]. Class matches the rune ranges given by pairs in Runes, or their complement if Negated.
+// Repeat matches its only child between Min and Max times, a Max of -1 means unbounded.
+// Group is capturing group number Index or a non-capturing group if Index is 0.
+// Backref matches the text captured by group Index.
+// Lookahead checks its only child without consuming, Negated inverts the check.
+type Node struct {
+	Type     NodeType
+	Runes    []rune
+	Negated  bool
+	Min      int
+	Max      int
+	Index    int
+	Children []*Node
+}
+
+// parser is a recursive descent parser for the supported rule syntax.
+type parser struct {
+	rule   []rune
+	pos    int
+	groups int
+}
+
+// Parse parses a rule into its syntax tree and returns the number of capturing groups.
+func Parse(rule string) (*Node, int, error) {
+	if !utf8.ValidString(rule) {
+		return nil, 0, fmt.Errorf("invalid utf-8 in rule %q", rule)
+	}
+	p := &parser{rule: []rune(rule)}
+	node, err := p.parseAlternate()
+	if err != nil {
+		return nil, 0, err
+	}
+	if !p.done() {
+		return nil, 0, p.errorf("unexpected )")
+	}
+	if index, ok := maxBackref(node); ok && index > p.groups {
+		return nil, 0, fmt.Errorf("backreference \\%d to missing group in rule %q", index, rule)
+	}
+	return node, p.groups, nil
+}
+
+func (p *parser) errorf(format string, args ...any) error {
+	return fmt.Errorf("%s at position %d in rule %q", fmt.Sprintf(format, args...), p.pos, string(p.rule))
+}
+
+func (p *parser) done() bool {
+	return p.pos >= len(p.rule)
+}
+
+func (p *parser) peek() rune {
+	return p.rule[p.pos]
+}
+
+func (p *parser) lookingAt(prefix string) bool {
+	runes := []rune(prefix)
+	return p.pos+len(runes) <= len(p.rule) && slices.Equal(p.rule[p.pos:p.pos+len(runes)], runes)
+}
+
+func (p *parser) parseAlternate() (*Node, error) {
+	var children []*Node
+	for {
+		child, err := p.parseConcat()
+		if err != nil {
+			return nil, err
+		}
+		children = append(children, child)
+		if p.done() || p.peek() != '|' {
+			break
+		}
+		p.pos++
+	}
+	if len(children) == 1 {
+		return children[0], nil
+	}
+	return &Node{Type: Alternate, Children: children}, nil
+}
+
+func (p *parser) parseConcat() (*Node, error) {
+	node := &Node{Type: Concat}
+	for !p.done() && p.peek() != '|' && p.peek() != ')' {
+		child, err := p.parseRepeat()
+		if err != nil {
+			return nil, err
+		}
+		node.Children = append(node.Children, child)
+	}
+	if len(node.Children) == 1 {
+		return node.Children[0], nil
+	}
+	return node, nil
+}
+
+func (p *parser) parseRepeat() (*Node, error) {
+	node, err := p.parseAtom()
+	if err != nil {
+		return nil, err
+	}
+	for !p.done() {
+		minCount, maxCount, ok := p.parseQuantifier()
+		if !ok {
+			break
+		}
+		if node.Type == Begin || node.Type == End || node.Type == Lookahead {
+			return nil, p.errorf("quantifier without repeatable argument")
+		}
+		// lazy quantifiers match the same strings when the whole line has to match
+		if !p.done() && p.peek() == '?' {
+			p.pos++
+		}
+		node = &Node{Type: Repeat, Min: minCount, Max: maxCount, Children: []*Node{node}}
+	}
+	return node, nil
+}
+
+// parseQuantifier reads *, +, ?, {m}, {m,} or {m,n}. Fails without consuming if there is none.
+func (p *parser) parseQuantifier() (int, int, bool) {
+	switch p.peek() {
+	case '*':
+		p.pos++
+		return 0, -1, true
+	case '+':
+		p.pos++
+		return 1, -1, true
+	case '?':
+		p.pos++
+		return 0, 1, true
+	case '{':
+		start := p.pos
+		p.pos++
+		minCount, ok := p.parseNumber()
+		if !ok {
+			p.pos = start
+			return 0, 0, false
+		}
+		maxCount := minCount
+		if !p.done() && p.peek() == ',' {
+			p.pos++
+			maxCount = -1
+			if number, ok := p.parseNumber(); ok {
+				maxCount = number
+			}
+		}
+		if p.done() || p.peek() != '}' || (maxCount != -1 && maxCount < minCount) {
+			p.pos = start
+			return 0, 0, false
+		}
+		p.pos++
+		return minCount, maxCount, true
+	}
+	return 0, 0, false
+}
+
+func (p *parser) parseNumber() (int, bool) {
+	start := p.pos
+	for !p.done() && p.peek() >= '0' && p.peek() <= '9' {
+		p.pos++
+	}
+	if start == p.pos {
+		return 0, false
+	}
+	number, err := strconv.Atoi(string(p.rule[start:p.pos]))
+	if err != nil || number > 1000 {
+		p.pos = start
+		return 0, false
+	}
+	return number, true
+}
+
+func (p *parser) parseAtom() (*Node, error) {
+	char := p.peek()
+	switch char {
+	case '(':
+		return p.parseGroup()
+	case '[':
+		return p.parseClass()
+	case '.':
+		p.pos++
+		return &Node{Type: Any}, nil
+	case '^':
+		p.pos++
+		return &Node{Type: Begin}, nil
+	case '$':
+		p.pos++
+		return &Node{Type: End}, nil
+	case '\\':
+		return p.parseEscape()
+	case '*', '+', '?':
+		return nil, p.errorf("missing argument to repetition operator %c", char)
+	}
+	p.pos++
+	return &Node{Type: Char, Runes: []rune{char}}, nil
+}
+
+func (p *parser) parseGroup() (*Node, error) {
+	var node *Node
+	switch {
+	case p.lookingAt("(?:"):
+		p.pos += 3
+		node = &Node{Type: Group}
+	case p.lookingAt("(?="):
+		p.pos += 3
+		node = &Node{Type: Lookahead}
+	case p.lookingAt("(?!"):
+		p.pos += 3
+		node = &Node{Type: Lookahead, Negated: true}
+	case p.lookingAt("(?"):
+		return nil, p.errorf("unsupported group")
+	default:
+		p.pos++
+		p.groups++
+		node = &Node{Type: Group, Index: p.groups}
+	}
+	child, err := p.parseAlternate()
+	if err != nil {
+		return nil, err
+	}
+	if p.done() || p.peek() != ')' {
+		return nil, p.errorf("missing )")
+	}
+	p.pos++
+	node.Children = []*Node{child}
+	return node, nil
+}
+
+func (p *parser) parseEscape() (*Node, error) {
+	p.pos++
+	if p.done() {
+		return nil, p.errorf("trailing backslash")
+	}
+	char := p.peek()
+	if char >= '1' && char <= '9' {
+		index, _ := p.parseNumber()
+		return &Node{Type: Backref, Index: index}, nil
+	}
+	p.pos++
+	if class, ok := escapeClass(char); ok {
+		return class, nil
+	}
+	if literal, ok := escapeLiteral(char); ok {
+		return &Node{Type: Char, Runes: []rune{literal}}, nil
+	}
+	return nil, p.errorf("unsupported escape \\%c", char)
+}
+
+// escapeClass returns the class of \d, \D, \w, \W, \s and \S.
+func escapeClass(char rune) (*Node, bool) {
+	var ranges []rune
+	switch char {
+	case 'd', 'D':
+		ranges = []rune{'0', '9'}
+	case 'w', 'W':
+		ranges = []rune{'0', '9', 'A', 'Z', '_', '_', 'a', 'z'}
+	case 's', 'S':
+		ranges = []rune{'\t', '\n', '\f', '\r', ' ', ' '}
+	default:
+		return nil, false
+	}
+	return &Node{Type: Class, Runes: ranges, Negated: char == 'D' || char == 'W' || char == 'S'}, true
+}
+
+// escapeLiteral returns the character of an escaped punctuation or control character.
+func escapeLiteral(char rune) (rune, bool) {
+	switch char {
+	case 'n':
+		return '\n', true
+	case 't':
+		return '\t', true
+	case 'r':
+		return '\r', true
+	case 'f':
+		return '\f', true
+	}
+	if char < utf8.RuneSelf && !('0' <= char && char <= '9') && !('a' <= char && char <= 'z') && !('A' <= char && char <= 'Z') {
+		return char, true
+	}
+	return 0, false
+}
+
+func (p *parser) parseClass() (*Node, error) {
+	p.pos++
+	node := &Node{Type: Class}
+	if !p.done() && p.peek() == '^' {
+		node.Negated = true
+		p.pos++
+	}
+	first := true
+	for !p.done() && (p.peek() != ']' || first) {
+		first = false
+		low, class, err := p.parseClassChar()
+		if err != nil {
+			return nil, err
+		}
+		if class != nil {
+			if class.Negated {
+				return nil, p.errorf("negated escape inside class")
+			}
+			node.Runes = append(node.Runes, class.Runes...)
+			continue
+		}
+		high := low
+		if p.lookingAt("-") && !p.lookingAt("-]") {
+			p.pos++
+			high, class, err = p.parseClassChar()
+			if err != nil {
+				return nil, err
+			}
+			if class != nil || high < low {
+				return nil, p.errorf("invalid class range")
+			}
+		}
+		node.Runes = append(node.Runes, low, high)
+	}
+	if p.done() {
+		return nil, p.errorf("missing ]")
+	}
+	p.pos++
+	return node, nil
+}
+
+// parseClassChar reads a single character or an escape of a class.
+func (p *parser) parseClassChar() (rune, *Node, error) {
+	char := p.peek()
+	p.pos++
+	if char != '\\' {
+		return char, nil, nil
+	}
+	if p.done() {
+		return 0, nil, p.errorf("trailing backslash")
+	}
+	char = p.peek()
+	p.pos++
+	if class, ok := escapeClass(char); ok {
+		return 0, class, nil
+	}
+	if literal, ok := escapeLiteral(char); ok {
+		return literal, nil, nil
+	}
+	return 0, nil, p.errorf("unsupported escape \\%c", char)
+}
+
+// maxBackref returns the largest group number referenced by a backreference.
+func maxBackref(node *Node) (int, bool) {
+	index, ok := 0, false
+	if node.Type == Backref {
+		index, ok = node.Index, true
+	}
+	for _, child := range node.Children {
+		if childIndex, childOk := maxBackref(child); childOk && childIndex > index {
+			index, ok = childIndex, true
+		}
+	}
+	return index, ok
+}
+
+// MatchRune checks, whether a Char, Class or Any node matches a single rune.
+func (node *Node) MatchRune(char rune) bool {
+	switch node.Type {
+	case Char:
+		return node.Runes[0] == char
+	case Any:
+		return char != '\n'
+	case Class:
+		for i := 0; i+1 < len(node.Runes); i += 2 {
+			if node.Runes[i] <= char && char <= node.Runes[i+1] {
+				return !node.Negated
+			}
+		}
+		return node.Negated
+	default:
+		return false
+	}
+}
@@ -0,0 +1,41 @@
+package matcher
+
+import "testing"
+
+func TestParse(t *testing.T) {
+	node, groups, err := Parse(`(a|b)(?:c\1)*`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if groups != 1 {
+		t.Errorf("Parse found %d groups, expected 1.", groups)
+	}
+	if node.Type != Concat || len(node.Children) != 2 {
+		t.Fatalf("Parse returned an incorrect root.")
+	}
+	if node.Children[0].Type != Group || node.Children[0].Children[0].Type != Alternate {
+		t.Errorf("Parse returned an incorrect group.")
+	}
+	repeat := node.Children[1]
+	if repeat.Type != Repeat || repeat.Min != 0 || repeat.Max != -1 || repeat.Children[0].Type != Group || repeat.Children[0].Index != 0 {
+		t.Errorf("Parse returned an incorrect repetition.")
+	}
+}
+
+func TestParse_Literals(t *testing.T) {
+	node, _, err := Parse(`a{,2}\.`)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	if len(node.Children) != 6 {
+		t.Errorf("Parse should treat an invalid repetition as literals, got %d nodes.", len(node.Children))
+	}
+}
+
+func TestParse_Invalid(t *testing.T) {
+	for _, rule := range []string{"(a", "a)", "*a", "[ab", `(a)\2`, `a\`, "(?<n>a)", "a{3,2}x\\q"} {
+		if _, _, err := Parse(rule); err == nil {
+			t.Errorf("Parse accepts the invalid rule %s.", rule)
+		}
+	}
+}
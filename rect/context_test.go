@@ -0,0 +1,62 @@
+package rect
+
+import (
+	"context"
+	"crossmatcher/collection"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCrossword_SolveContextCancelled(t *testing.T) {
+	horizontal := []string{".*", ".*", "a.*"}
+	vertical := []string{".*", ".*", ".*"}
+	alphabet := collection.MakeAlphabet("abcd")
+	crossword := MakeCrossword(alphabet, horizontal, vertical)
+	constraint := MakeCandidateEmpty(alphabet, 3, 3)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	partial, _, err := crossword.SolveBruteforceContext(ctx, constraint)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("SolveBruteforceContext does not report the cancellation, got %v", err)
+	}
+	if partial.String() != constraint.String() {
+		t.Errorf("SolveBruteforceContext does not return the constraint. Got %s", partial.String())
+	}
+
+	_, _, err = crossword.SolveLinearReductionsContext(ctx, constraint)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("SolveLinearReductionsContext does not report the cancellation, got %v", err)
+	}
+
+	_, _, err = crossword.SolveBacktrackingContext(ctx, constraint)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("SolveBacktrackingContext does not report the cancellation, got %v", err)
+	}
+
+	generated, err := MakeRandomCrosswordContext(ctx, alphabet, 3, 4)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("MakeRandomCrosswordContext does not report the cancellation, got %v", err)
+	}
+	if len(generated.Horizontal) != 3 || len(generated.Vertical) != 4 || !generated.hasUniqueSolution() {
+		t.Errorf("MakeRandomCrosswordContext does not return a unique partial crossword: %s", generated)
+	}
+}
+
+func TestCrossword_SolveBruteforceDeadline(t *testing.T) {
+	horizontal := []string{".*", ".*", ".*", ".*"}
+	vertical := []string{".*", ".*", ".*", ".*"}
+	alphabet := collection.MakeAlphabet("abcd")
+	crossword := MakeCrossword(alphabet, horizontal, vertical)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, count, err := crossword.SolveBruteforceContext(ctx, MakeCandidateEmpty(alphabet, 4, 4))
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("SolveBruteforceContext does not report the deadline, got %v", err)
+	}
+	if count == 0 {
+		t.Errorf("SolveBruteforceContext does not report the solutions found before the deadline.")
+	}
+}
@@ -0,0 +1,98 @@
+package rect
+
+import (
+	"crossmatcher/lin"
+	"encoding/binary"
+	"math/big"
+)
+
+// CountSolutionsExact returns the exact number of solutions satisfying the constraint.
+// In contrast to CountSolutions it does not enumerate the solutions, but fills the grid row by row
+// and counts the ways to reach each combination of column automaton states.
+func (c Crossword) CountSolutionsExact(constraint Candidate) *big.Int {
+	domain, rounds := c.ReduceDomain(MakeDomain(constraint, c.Alphabet))
+	if rounds == 0 {
+		return big.NewInt(0)
+	}
+	height, width := len(c.Horizontal), len(c.Vertical)
+
+	cols := make([]lin.Automaton, width)
+	colLive := make([][][]bool, width)
+	for j, rule := range c.Vertical {
+		var ok bool
+		if cols[j], ok = lin.CompileLineAutomaton(rule, domain.Alphabet, height); !ok {
+			return big.NewInt(0)
+		}
+		col, _ := domain.GetCol(j)
+		colLive[j] = cols[j].Live(col.Sets)
+	}
+
+	start := make([]int, width)
+	for j, col := range cols {
+		start[j] = col.Start
+	}
+	counts := map[string]*big.Int{stateKey(start): big.NewInt(1)}
+	tuples := map[string][]int{stateKey(start): start}
+
+	for i, rule := range c.Horizontal {
+		row, ok := lin.CompileLineAutomaton(rule, domain.Alphabet, width)
+		if !ok {
+			return big.NewInt(0)
+		}
+		rowLive := row.Live(domain.Sets[i])
+		nextCounts := make(map[string]*big.Int)
+		nextTuples := make(map[string][]int)
+		for key, count := range counts {
+			states := tuples[key]
+			next := make([]int, width)
+			var fill func(j int, rowState int)
+			fill = func(j int, rowState int) {
+				if j == width {
+					nextKey := stateKey(next)
+					if _, ok := nextCounts[nextKey]; !ok {
+						nextCounts[nextKey] = big.NewInt(0)
+						nextTuples[nextKey] = append([]int(nil), next...)
+					}
+					nextCounts[nextKey].Add(nextCounts[nextKey], count)
+					return
+				}
+				for _, num := range domain.Sets[i][j].Numbers() {
+					nextRowState := row.Next[rowState][num]
+					if nextRowState == -1 || !rowLive[j+1][nextRowState] {
+						continue
+					}
+					next[j] = cols[j].Next[states[j]][num]
+					if next[j] == -1 || !colLive[j][i+1][next[j]] {
+						continue
+					}
+					fill(j+1, nextRowState)
+				}
+			}
+			if rowLive[0][row.Start] {
+				fill(0, row.Start)
+			}
+		}
+		counts, tuples = nextCounts, nextTuples
+	}
+
+	total := big.NewInt(0)
+	for key, count := range counts {
+		accepted := true
+		for j, state := range tuples[key] {
+			accepted = accepted && cols[j].Accepting[state]
+		}
+		if accepted {
+			total.Add(total, count)
+		}
+	}
+	return total
+}
+
+// stateKey encodes a tuple of automaton states as a map key.
+func stateKey(states []int) string {
+	var key []byte
+	for _, state := range states {
+		key = binary.AppendUvarint(key, uint64(state))
+	}
+	return string(key)
+}
@@ -0,0 +1,32 @@
+package rect
+
+import (
+	"crossmatcher/collection"
+	"testing"
+)
+
+func TestCrossword_CountSolutionsExact(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	crosswords := []Crossword{
+		MakeCrossword(alphabet, []string{"a.*", "[bc]+", ".*c"}, []string{"a|b|c.*", ".b.", "..."}),
+		MakeCrossword(alphabet, []string{"(ab|c)+", ".*", "[ac]*"}, []string{".*", "(a|bc)*.", "a?.*"}),
+		MakeCrossword(alphabet, []string{"(.)*", "a*b*c*", "c*b*a*"}, []string{"[ab].c", ".*", "a.*|.*c"}),
+		MakeCrossword(alphabet, []string{"(.)\\1.", ".*", "(?!a).*"}, []string{".*", "(.)(?!\\1)..", "[ab]*"}),
+		MakeCrossword(alphabet, []string{"a", ".*", "[ab]*"}, []string{".*", "..", "[ab]*"}),
+	}
+	constraints := []Candidate{MakeCandidateEmpty(alphabet, 3, 3), MakeCandidate([]string{"...", ".b.", "..."}, '.')}
+	for _, crossword := range crosswords {
+		for _, constraint := range constraints {
+			_, expected := crossword.SolveBruteforce(constraint)
+			if actual := crossword.CountSolutionsExact(constraint); actual.Int64() != int64(expected) {
+				t.Errorf("CountSolutionsExact is incorrect on %v with %s. Expected %d, got %s", crossword, constraint.String(), expected, actual)
+			}
+		}
+	}
+
+	crossword := MakeCrossword(alphabet, []string{".*", ".*", ".*", ".*", ".*"}, []string{".*", ".*", ".*", ".*", "a*"})
+	expected := "3486784401"
+	if actual := crossword.CountSolutionsExact(MakeCandidateEmpty(alphabet, 5, 5)); actual.String() != expected {
+		t.Errorf("CountSolutionsExact is incorrect on a large grid. Expected %s, got %s", expected, actual)
+	}
+}
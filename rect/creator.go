@@ -1,27 +1,110 @@
 package rect
 
 import (
+	"context"
 	"crossmatcher/collection"
 	"crossmatcher/lin"
+	"errors"
+	"fmt"
 	"math/rand"
+	"time"
 )
 
 type TransformationType int
 
 const (
-	Merge int = iota
+	Merge TransformationType = iota
 	Extend
 	Shorten
+	Widen
+	Negate
+	Wildcard
+	Optional
+	Quantify
+	Star
+	Nest
 )
 
+// DefaultTransformationWeights returns the relative frequencies of the transformations.
+func DefaultTransformationWeights() map[TransformationType]float64 {
+	return map[TransformationType]float64{
+		Merge: 8, Extend: 1, Shorten: 1,
+		Widen: 1, Negate: 0.5, Wildcard: 0.25, Optional: 0.5, Quantify: 0.5, Star: 0.5, Nest: 0.5,
+	}
+}
+
+// checkTransformationWeights checks that the weights only belong to known transformations, are not negative
+// and that at least one is positive.
+func checkTransformationWeights(weights map[TransformationType]float64) error {
+	sum := 0.0
+	for transformation, weight := range weights {
+		if transformation < Merge || transformation > Nest {
+			return fmt.Errorf("weight of unknown transformation %d", transformation)
+		}
+		if weight < 0 {
+			return fmt.Errorf("negative weight %v of transformation %d", weight, transformation)
+		}
+		sum += weight
+	}
+	if sum <= 0 {
+		return errors.New("no transformation has a positive weight")
+	}
+	return nil
+}
+
 type CrosswordTree struct {
 	Horizontal []lin.RegexNode
 	Vertical   []lin.RegexNode
 	Alphabet   collection.Alphabet
+	// isUnique decides, whether a transformation keeps the solution unique. Nil uses line logic.
+	isUnique func(Crossword) bool
 }
 
 func MakeRandomCrossword(alphabet collection.Alphabet, height, width int) Crossword {
-	trivial := MakeCrosswordRandomTrivial(alphabet, height, width)
+	crossword, _ := MakeRandomCrosswordContext(context.Background(), alphabet, height, width)
+	return crossword
+}
+
+// MakeRandomCrosswordContext does the same as MakeRandomCrossword, but stops transforming the rules when the
+// context is done. It then returns the context error together with the crossword built so far,
+// which still has a unique solution.
+func MakeRandomCrosswordContext(ctx context.Context, alphabet collection.Alphabet, height, width int) (Crossword, error) {
+	generation, err := Generate(ctx, alphabet, height, width, rand.Int63())
+	return generation.Crossword, err
+}
+
+// Generate does the same as MakeRandomCrosswordContext, but draws all random choices from a generator
+// seeded with seed. The generation also holds the solution and the difficulty of the crossword.
+func Generate(ctx context.Context, alphabet collection.Alphabet, height, width int, seed int64) (Generation, error) {
+	start := time.Now()
+	rng := rand.New(rand.NewSource(seed))
+	ret, solution := makeSeparatedCrosswordTree(alphabet, height, width, 0, rng)
+	generation := Generation{Solution: solution, Seed: seed}
+	generation.Stats.Attempts = 1
+
+	var err error
+	for range 5 * (height + width) {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		var changed bool
+		ret, changed = ret.transformSingleRule(alphabet, DefaultTransformationWeights(), rng)
+		generation.Stats.addStep(changed)
+	}
+
+	ret = ret.finalSeparationTransformations(rng)
+
+	generation.Crossword = ret.ToCrossword()
+	generation.Difficulty, _ = generation.Crossword.RateDifficulty(MakeCandidateEmpty(alphabet, height, width))
+	generation.Stats.Duration = time.Since(start)
+	return generation, err
+}
+
+// makeSeparatedCrosswordTree makes the rule trees of a random trivial crossword split into repeated blocks
+// and returns them together with the solution of the trivial crossword.
+// The tilt shifts the block sizes as in lin.BlockProbabilityAcc.
+func makeSeparatedCrosswordTree(alphabet collection.Alphabet, height, width int, tilt float64, rng *rand.Rand) (CrosswordTree, Candidate) {
+	trivial, solution := makeCrosswordRandomTrivial(alphabet, height, width, rng)
 	horizontal := make([]lin.RegexNode, height)
 	vertical := make([]lin.RegexNode, width)
 	for i, rule := range trivial.Horizontal {
@@ -31,25 +114,31 @@ func MakeRandomCrossword(alphabet collection.Alphabet, height, width int) Crossw
 		vertical[i] = lin.MakeRegexNode(rule)
 	}
 	ret := CrosswordTree{Horizontal: horizontal, Vertical: vertical, Alphabet: alphabet}
-	ret = ret.initialSeparationTransformations()
-
-	for range 5 * (height + width) {
-		ret = ret.transformSingleRule(alphabet)
-	}
-
-	ret = ret.finalSeparationTransformations()
+	return ret.initialSeparationTransformations(tilt, rng), solution
+}
 
-	return ret.ToCrossword()
+// MakeRandomCrosswordRated does the same as MakeRandomCrossword and rates the difficulty of solving
+// the generated crossword from an empty grid.
+func MakeRandomCrosswordRated(alphabet collection.Alphabet, height, width int) (Crossword, Difficulty) {
+	generation, _ := Generate(context.Background(), alphabet, height, width, rand.Int63())
+	return generation.Crossword, generation.Difficulty
 }
 
 // MakeCrosswordRandomTrivial makes a random trivial crossword over an underlying alphabet with given size.
 func MakeCrosswordRandomTrivial(alphabet collection.Alphabet, height, width int) Crossword {
+	crossword, _ := makeCrosswordRandomTrivial(alphabet, height, width, rand.New(rand.NewSource(rand.Int63())))
+	return crossword
+}
+
+// makeCrosswordRandomTrivial does the same as MakeCrosswordRandomTrivial, but draws the characters from rng
+// and also returns the only solution.
+func makeCrosswordRandomTrivial(alphabet collection.Alphabet, height, width int, rng *rand.Rand) (Crossword, Candidate) {
 	runes := []rune(alphabet.String())
 	solution := make([][]rune, height)
 	for i := range height {
 		row := make([]rune, width)
 		for j := range width {
-			row[j] = runes[rand.Intn(len(runes))]
+			row[j] = runes[rng.Intn(len(runes))]
 		}
 		solution[i] = row
 	}
@@ -61,7 +150,7 @@ func MakeCrosswordRandomTrivial(alphabet collection.Alphabet, height, width int)
 			vertical[j] += string(solution[i][j])
 		}
 	}
-	return MakeCrossword(alphabet, horizontal, vertical)
+	return MakeCrossword(alphabet, horizontal, vertical), MakeCandidate(horizontal)
 }
 
 func (c CrosswordTree) DeepCopy() CrosswordTree {
@@ -77,6 +166,7 @@ func (c CrosswordTree) DeepCopy() CrosswordTree {
 	ret.Horizontal = horizontal
 	ret.Vertical = vertical
 	ret.Alphabet = c.Alphabet
+	ret.isUnique = c.isUnique
 	return ret
 }
 
@@ -92,9 +182,9 @@ func (c CrosswordTree) ToCrossword() Crossword {
 	return MakeCrossword(c.Alphabet, horizontal, vertical)
 }
 
-func (c CrosswordTree) getRandomRuleRef() *lin.RegexNode {
+func (c CrosswordTree) getRandomRuleRef(rng *rand.Rand) *lin.RegexNode {
 	dimSum := len(c.Horizontal) + len(c.Vertical)
-	rule := rand.Intn(dimSum)
+	rule := rng.Intn(dimSum)
 	if rule < len(c.Horizontal) {
 		return &c.Horizontal[rule]
 
@@ -104,93 +194,132 @@ func (c CrosswordTree) getRandomRuleRef() *lin.RegexNode {
 	}
 }
 
-func (c CrosswordTree) transformSingleRule(alphabet collection.Alphabet) CrosswordTree {
-	ruleRef := c.getRandomRuleRef()
-	rule := getTransformationNumber()
-	switch rule {
+// transformSingleRule transforms a random rule, as long as the crossword keeps a unique solution.
+// The transformation is drawn by the weights. It also reports, whether the rule changed.
+func (c CrosswordTree) transformSingleRule(alphabet collection.Alphabet, weights map[TransformationType]float64, rng *rand.Rand) (CrosswordTree, bool) {
+	ruleRef := c.getRandomRuleRef(rng)
+	before := ruleRef.String()
+	switch getTransformation(weights, rng) {
 	case Merge:
-		return c.MergeBlocks(ruleRef)
+		c = c.MergeBlocks(ruleRef, rng)
 	case Extend:
-		return c.ExtendAlternationElement(ruleRef, alphabet)
+		c = c.ExtendAlternationElement(ruleRef, alphabet, rng)
 	case Shorten:
-		return c.ShortenAlternationElement(ruleRef)
-	default:
-		return c
+		c = c.ShortenAlternationElement(ruleRef, rng)
+	case Widen:
+		c = c.tryRuleChange(ruleRef, ruleRef.WidenRandomCharacter(alphabet, rng))
+	case Negate:
+		c = c.tryRuleChange(ruleRef, ruleRef.NegateRandomCharacter(alphabet, rng))
+	case Wildcard:
+		c = c.tryRuleChange(ruleRef, ruleRef.WildcardRandomCharacter(rng))
+	case Optional:
+		c = c.tryRuleChange(ruleRef, ruleRef.OptionalRandomCharacter(rng))
+	case Quantify:
+		c = c.tryRuleChange(ruleRef, ruleRef.QuantifyRandomCharacter(rng))
+	case Star:
+		c = c.tryRuleChange(ruleRef, ruleRef.StarRandomBlock(rng))
+	case Nest:
+		c = c.tryRuleChange(ruleRef, ruleRef.NestRandomRun(rng))
 	}
+	return c, ruleRef.String() != before
 }
 
-func getTransformationProbabilityAcc() []float64 {
-	return []float64{0.8, 0.90}
+// getTransformationProbabilityAcc accumulates the weights of all transformations but the last to probabilities,
+// in the order of their constants
+func getTransformationProbabilityAcc(weights map[TransformationType]float64) []float64 {
+	// summing in the order of the constants makes the probability up to the last weighted transformation exactly 1
+	sum := 0.0
+	for transformation := Merge; transformation <= Nest; transformation++ {
+		sum += weights[transformation]
+	}
+	acc := 0.0
+	transformationProbabilityAcc := make([]float64, Nest)
+	for transformation := Merge; transformation < Nest; transformation++ {
+		acc += weights[transformation]
+		transformationProbabilityAcc[transformation] = acc / sum
+	}
+	return transformationProbabilityAcc
 }
 
-func getTransformationNumber() int {
-	blockProbabilityAcc := getTransformationProbabilityAcc()
-	randomVal := rand.Float64()
-	for i, acc := range blockProbabilityAcc {
-		if randomVal < acc {
-			return i
+func getTransformation(weights map[TransformationType]float64, rng *rand.Rand) TransformationType {
+	transformationProbabilityAcc := getTransformationProbabilityAcc(weights)
+	randomVal := rng.Float64()
+	for i, acc := range transformationProbabilityAcc {
+		if randomVal < acc && weights[TransformationType(i)] > 0 {
+			return TransformationType(i)
 		}
 	}
-	return len(blockProbabilityAcc)
+	// only reached if Nest takes the rest, the last transformation with a weight is drawn instead
+	last := Nest
+	for last > Merge && weights[last] <= 0 {
+		last--
+	}
+	return last
 }
 
-func (c CrosswordTree) MergeBlocks(ruleRef *lin.RegexNode) CrosswordTree {
+func (c CrosswordTree) MergeBlocks(ruleRef *lin.RegexNode, rng *rand.Rand) CrosswordTree {
 	rule := *ruleRef
-	rule = rule.MergeRandomBlocks()
+	rule = rule.MergeRandomBlocksRand(rng)
 	return c.tryRuleChange(ruleRef, rule)
 }
 
-func (c CrosswordTree) ExtendAlternationElement(ruleRef *lin.RegexNode, alphabet collection.Alphabet) CrosswordTree {
+func (c CrosswordTree) ExtendAlternationElement(ruleRef *lin.RegexNode, alphabet collection.Alphabet, rng *rand.Rand) CrosswordTree {
 	rule := *ruleRef
-	rule = rule.ExtendRandomAlternationElement(alphabet)
+	rule = rule.ExtendRandomAlternationElementRand(alphabet, rng)
 	return c.tryRuleChange(ruleRef, rule)
 }
 
-func (c CrosswordTree) ShortenAlternationElement(ruleRef *lin.RegexNode) CrosswordTree {
+func (c CrosswordTree) ShortenAlternationElement(ruleRef *lin.RegexNode, rng *rand.Rand) CrosswordTree {
 	rule := *ruleRef
-	rule = rule.ShortenRandomAlternationElement()
+	rule = rule.ShortenRandomAlternationElementRand(rng)
 	return c.tryRuleChange(ruleRef, rule)
 }
 
 func (c CrosswordTree) tryRuleChange(ruleRef *lin.RegexNode, newRule lin.RegexNode) CrosswordTree {
 	oldRule := *ruleRef
 	*ruleRef = newRule
-	if !c.ToCrossword().hasUniqueSolution() {
+	isUnique := c.isUnique
+	if isUnique == nil {
+		isUnique = Crossword.hasUniqueSolution
+	}
+	if !isUnique(c.ToCrossword()) {
 		*ruleRef = oldRule
 	}
 	return c
 }
 
-// applyInitialSeparationTransformations applies a sequence of transformations to a slice of RegexNodes
-func applyInitialSeparationTransformations(rules []lin.RegexNode) []lin.RegexNode {
+// applyInitialSeparationTransformations applies a sequence of transformations to a slice of RegexNodes,
+// the tilt shifts the block sizes as in lin.BlockProbabilityAcc
+func applyInitialSeparationTransformations(rules []lin.RegexNode, tilt float64, rng *rand.Rand) []lin.RegexNode {
 	newRules := make([]lin.RegexNode, len(rules))
 	for i, rule := range rules {
 		newRules[i] = rule.
-			SeparateIntoBlocks().
+			SeparateIntoBlocksWith(lin.BlockProbabilityAcc(len(rule.Children), tilt), rng).
 			WithAlternationSubgroups().
 			WithRepetitionSubgroups()
 	}
 	return newRules
 }
 
-func applyFinalSeparationTransformations(rules []lin.RegexNode) []lin.RegexNode {
+// applyFinalSeparationTransformations simplifies rules matching rows of the given length and shuffles their alternations
+func applyFinalSeparationTransformations(rules []lin.RegexNode, length int, rng *rand.Rand) []lin.RegexNode {
 	newRules := make([]lin.RegexNode, len(rules))
 	for i, rule := range rules {
-		newRules[i] = rule.SimplifyAlternations().RandomizeAlternations()
+		newRules[i] = rule.Simplify(length).RandomizeAlternationsRand(rng)
 	}
 	return newRules
 }
 
-func (c CrosswordTree) initialSeparationTransformations() CrosswordTree {
+func (c CrosswordTree) initialSeparationTransformations(tilt float64, rng *rand.Rand) CrosswordTree {
 	ret := c.DeepCopy()
-	ret.Horizontal = applyInitialSeparationTransformations(ret.Horizontal)
-	ret.Vertical = applyInitialSeparationTransformations(ret.Vertical)
+	ret.Horizontal = applyInitialSeparationTransformations(ret.Horizontal, tilt, rng)
+	ret.Vertical = applyInitialSeparationTransformations(ret.Vertical, tilt, rng)
 	return ret
 }
 
-func (c CrosswordTree) finalSeparationTransformations() CrosswordTree {
+func (c CrosswordTree) finalSeparationTransformations(rng *rand.Rand) CrosswordTree {
 	ret := c.DeepCopy()
-	ret.Horizontal = applyFinalSeparationTransformations(ret.Horizontal)
-	ret.Vertical = applyFinalSeparationTransformations(ret.Vertical)
+	ret.Horizontal = applyFinalSeparationTransformations(ret.Horizontal, len(ret.Vertical), rng)
+	ret.Vertical = applyFinalSeparationTransformations(ret.Vertical, len(ret.Horizontal), rng)
 	return ret
 }
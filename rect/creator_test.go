@@ -0,0 +1,165 @@
+package rect
+
+import (
+	"context"
+	"crossmatcher/collection"
+	"crossmatcher/lin"
+	"math/rand"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestGenerate(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	generation, err := Generate(context.Background(), alphabet, 3, 4, 42)
+	if err != nil {
+		t.Fatalf("Generate fails: %v", err)
+	}
+	if generation.Seed != 42 || generation.Stats.Attempts != 1 || generation.Stats.Steps != 35 || generation.Stats.Changes > generation.Stats.Steps {
+		t.Errorf("Generate has incorrect metadata: %+v", generation)
+	}
+	if !generation.Crossword.CheckSolution(generation.Solution) || !generation.Crossword.hasUniqueSolution() {
+		t.Errorf("Generate has an incorrect solution %s of %v", generation.Solution.String(), generation.Crossword)
+	}
+	if generation.Difficulty.Label == "" {
+		t.Errorf("Generate does not rate the crossword: %+v", generation.Difficulty)
+	}
+
+	again, _ := Generate(context.Background(), collection.MakeAlphabet("abc"), 3, 4, 42)
+	if !sameGeneration(generation, again) {
+		t.Errorf("Generate is not reproducible: %v and %v", generation.Crossword, again.Crossword)
+	}
+	other, _ := Generate(context.Background(), alphabet, 3, 4, 43)
+	if sameGeneration(generation, other) {
+		t.Errorf("Generate ignores the seed: %v", other.Crossword)
+	}
+}
+
+func TestGenerateOptions(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	minScore, maxScore, _ := DifficultyBand("medium")
+	options := GeneratorOptions{MinScore: minScore, MaxScore: maxScore, Budget: time.Minute}
+	generation, ok, _ := GenerateOptions(alphabet, 4, 4, options, 7)
+	if !ok || generation.Seed != 7 || generation.Stats.Attempts == 0 {
+		t.Fatalf("GenerateOptions misses the band: %+v", generation)
+	}
+	if !generation.Crossword.CheckSolution(generation.Solution) {
+		t.Errorf("GenerateOptions has an incorrect solution %s of %v", generation.Solution.String(), generation.Crossword)
+	}
+	again, _, _ := GenerateOptions(alphabet, 4, 4, options, 7)
+	if !sameGeneration(generation, again) || generation.Stats.Steps != again.Stats.Steps {
+		t.Errorf("GenerateOptions is not reproducible: %v and %v", generation.Crossword, again.Crossword)
+	}
+}
+
+// sameGeneration compares two generations without their durations.
+func sameGeneration(generation Generation, other Generation) bool {
+	generation.Stats.Duration, other.Stats.Duration = 0, 0
+	return reflect.DeepEqual(generation.Crossword.Horizontal, other.Crossword.Horizontal) &&
+		reflect.DeepEqual(generation.Crossword.Vertical, other.Crossword.Vertical) &&
+		reflect.DeepEqual(generation.Solution, other.Solution) &&
+		generation.Difficulty == other.Difficulty && generation.Stats == other.Stats
+}
+
+func TestGenerateOptions_Weights(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	weights := make(map[TransformationType]float64)
+	for transformation := Widen; transformation <= Nest; transformation++ {
+		weights[transformation] = 1
+	}
+	options := GeneratorOptions{MinScore: 1000, Weights: weights}
+	syntax := ""
+	for seed := range int64(5) {
+		generation, _, _ := GenerateOptions(alphabet, 3, 3, options, seed)
+		if !generation.Crossword.CheckSolution(generation.Solution) || !generation.Crossword.hasUniqueSolution() {
+			t.Errorf("GenerateOptions loses the solution %s of %v", generation.Solution.String(), generation.Crossword)
+		}
+		if generation.Stats.Changes == 0 {
+			t.Errorf("GenerateOptions does not change the rules: %+v", generation.Stats)
+		}
+		syntax += strings.Join(generation.Crossword.Horizontal, "") + strings.Join(generation.Crossword.Vertical, "")
+	}
+	for _, expected := range []string{"[", "."} {
+		if !strings.Contains(syntax, expected) {
+			t.Errorf("GenerateOptions never introduces %s: %s", expected, syntax)
+		}
+	}
+}
+
+func TestGenerate_Simplified(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	for seed := range int64(5) {
+		generation, _ := Generate(context.Background(), alphabet, 3, 4, seed)
+		rules := append(slices.Clone(generation.Crossword.Horizontal), generation.Crossword.Vertical...)
+		for i, rule := range rules {
+			length := 4
+			if i >= len(generation.Crossword.Horizontal) {
+				length = 3
+			}
+			node, ok := lin.ParseRegexNode(rule)
+			if !ok {
+				t.Fatalf("Generate makes the unparsable rule %s", rule)
+			}
+			// simplifying again may only reorder alternatives and classes
+			simplified := node.Simplify(length).String()
+			if len(simplified) != len(rule) {
+				t.Errorf("Generate makes the rule %s, which simplifies to %s", rule, simplified)
+			}
+			if ok, row, _ := lin.Equivalent(rule, simplified, length, alphabet); !ok {
+				t.Errorf("Simplify changes the generated rule %s to %s, they differ on %s", rule, simplified, row)
+			}
+		}
+	}
+}
+
+func TestApplyFinalSeparationTransformations(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	node, _ := lin.ParseRegexNode("(ab|ab|a)+|abcab")
+	rules := applyFinalSeparationTransformations([]lin.RegexNode{node}, 4, rand.New(rand.NewSource(1)))
+	if actual := rules[0].String(); actual != "(ab?)+" {
+		t.Errorf("applyFinalSeparationTransformations does not simplify %s, got %s", node, actual)
+	}
+	if ok, row, _ := lin.Equivalent(node.String(), rules[0].String(), 4, alphabet); !ok {
+		t.Errorf("applyFinalSeparationTransformations changes %s to %s, they differ on %s", node, rules[0], row)
+	}
+}
+
+func TestGenerateOptions_InvalidWeights(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	for _, weights := range []map[TransformationType]float64{
+		{},
+		{Merge: 0, Nest: 0},
+		{Merge: 1, Extend: -1},
+		{Merge: 1, Nest + 1: 1},
+	} {
+		if generation, ok, err := GenerateOptions(alphabet, 3, 3, GeneratorOptions{Weights: weights}, 1); err == nil || ok || generation.Stats.Attempts != 0 {
+			t.Errorf("GenerateOptions accepts the weights %v", weights)
+		}
+	}
+}
+
+func TestGetTransformation(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	weights := map[TransformationType]float64{Merge: 0.1, Extend: 0.2, Shorten: 0.3, Widen: 0.7}
+	if transformation := getTransformation(weights, constantSource(0.9999999999999999)); transformation != Widen {
+		t.Errorf("getTransformation falls back to the transformation %d without weight", transformation)
+	}
+	for range 100 {
+		if transformation := getTransformation(map[TransformationType]float64{Extend: 1, Star: 2}, rng); transformation != Extend && transformation != Star {
+			t.Fatalf("getTransformation draws the transformation %d without weight", transformation)
+		}
+	}
+}
+
+// constantSource returns a generator whose Float64 always returns value.
+func constantSource(value float64) *rand.Rand {
+	return rand.New(constant(value * (1 << 63)))
+}
+
+type constant int64
+
+func (c constant) Int63() int64 { return int64(c) }
+func (c constant) Seed(int64)   {}
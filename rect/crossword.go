@@ -1,11 +1,15 @@
 package rect
 
 import (
+	"context"
 	"crossmatcher/collection"
 	"crossmatcher/lin"
 	"fmt"
 )
 
+// contextCheckInterval is the number of candidates the bruteforce solvers check between looking at their context.
+const contextCheckInterval = 1024
+
 type Crossword struct {
 	Horizontal []string
 	Vertical   []string
@@ -27,6 +31,21 @@ func (c Crossword) hasUniqueSolution() bool {
 	return c.CheckSolution(solution)
 }
 
+// hasUniqueSolutionParallel does the same as hasUniqueSolution, but solves the lines with the given number of goroutines.
+func (c Crossword) hasUniqueSolutionParallel(workers int) bool {
+	candidate := MakeCandidateEmpty(c.Alphabet, len(c.Horizontal), len(c.Vertical))
+	solution, _ := c.SolveLinearReductionsParallel(candidate, workers)
+	return c.CheckSolution(solution)
+}
+
+// hasUniqueSolutionBacktracking does the same as hasUniqueSolution, but also accepts crosswords
+// whose solution needs guessing. The search stops when the context is done, the crossword is then rejected.
+func (c Crossword) hasUniqueSolutionBacktracking(ctx context.Context) bool {
+	candidate := MakeCandidateEmpty(c.Alphabet, len(c.Horizontal), len(c.Vertical))
+	solutionNum, err := c.CountSolutionsContext(ctx, candidate, 2)
+	return err == nil && solutionNum == 1
+}
+
 func (c Crossword) GetRow(rowNumber int) (lin.Crossword, bool) {
 	if len(c.Horizontal) <= rowNumber {
 		return lin.MakeCrossword("", collection.MakeAlphabet("")), false
@@ -69,12 +88,24 @@ func (c Crossword) CheckSolution(candidate Candidate) bool {
 
 // SolveBruteforce checks all candidates that fill the wildcards given by the constraint.
 func (c Crossword) SolveBruteforce(constraint Candidate) (Candidate, int) {
+	solution, solutionNum, _ := c.SolveBruteforceContext(context.Background(), constraint)
+	return solution, solutionNum
+}
+
+// SolveBruteforceContext does the same as SolveBruteforce, but stops when the context is done.
+// It then returns the context error, the unchanged constraint and the number of solutions found so far.
+func (c Crossword) SolveBruteforceContext(ctx context.Context, constraint Candidate) (Candidate, int, error) {
 	candidateFill, _ := lin.MakeCandidateFirst(c.Alphabet, constraint.CountWildcards())
 
 	candidateIsValid := true
 	solutionNum := 0
 	var solution Candidate
-	for candidateIsValid {
+	for checked := 0; candidateIsValid; checked++ {
+		if checked%contextCheckInterval == 0 {
+			if err := ctx.Err(); err != nil {
+				return constraint.Copy(), solutionNum, err
+			}
+		}
 		candidateMerge, _ := constraint.Merge(candidateFill)
 		if c.CheckSolution(candidateMerge) {
 			solutionNum++
@@ -82,34 +113,37 @@ func (c Crossword) SolveBruteforce(constraint Candidate) (Candidate, int) {
 		}
 		candidateFill, candidateIsValid = candidateFill.IncrementCandidate()
 	}
-	return solution, solutionNum
+	return solution, solutionNum, nil
 }
 
+// SolveLinearReductions solves rows and columns one at a time until no further cell gets decided.
+// It returns the decided cells and the number of rounds, or 0 on a contradiction.
 func (c Crossword) SolveLinearReductions(constraint Candidate) (Candidate, int) {
-	next := constraint.Copy()
-	for rowNumber := range c.Horizontal {
-		rowRule := c.Horizontal[rowNumber]
-		rowCrossword := lin.MakeCrossword(rowRule, c.Alphabet)
-		rowConstraint, _ := next.GetRow(rowNumber)
-		rowSolved, rowNumSolutions := rowCrossword.SolveBruteforce(rowConstraint)
-		if rowNumSolutions == 0 {
-			return Candidate{}, 0
-		}
-		next, _ = next.UpdateRow(rowSolved, rowNumber)
+	candidate, depth, _ := c.SolveLinearReductionsContext(context.Background(), constraint)
+	return candidate, depth
+}
+
+// SolveLinearReductionsContext does the same as SolveLinearReductions, but stops when the context is done.
+// It then returns the context error, the cells decided so far and the number of started rounds.
+func (c Crossword) SolveLinearReductionsContext(ctx context.Context, constraint Candidate) (Candidate, int, error) {
+	domain := MakeDomain(constraint, c.Alphabet)
+	stats := PropagationStats{}
+	ok, err := c.propagate(ctx, domain, c.lineSolvers(domain), c.allLines(), &stats, nil)
+	if err != nil {
+		return domain.Candidate(), stats.Rounds, err
 	}
-	for colNumber := range c.Vertical {
-		colRule := c.Vertical[colNumber]
-		colCrossword := lin.MakeCrossword(colRule, c.Alphabet)
-		colConstraint, _ := next.GetCol(colNumber)
-		colSolved, colNumSolutions := colCrossword.SolveBruteforce(colConstraint)
-		if colNumSolutions == 0 {
-			return Candidate{}, 0
-		}
-		next, _ = next.UpdateCol(colSolved, colNumber)
+	if !ok {
+		return Candidate{}, 0, nil
 	}
-	if next.CountWildcards() == constraint.CountWildcards() {
-		return next, 1
+	return domain.Candidate(), max(stats.Rounds, 1), nil
+}
+
+// ReduceDomain narrows the sets of all cells by propagating the row and column rules until no set changes.
+// It returns the narrowed domain and the number of rounds, or 0 on a contradiction.
+func (c Crossword) ReduceDomain(domain Domain) (Domain, int) {
+	next, stats, ok := c.Propagate(domain)
+	if !ok {
+		return Domain{}, 0
 	}
-	result, depth := c.SolveLinearReductions(next)
-	return result, depth + 1
+	return next, max(stats.Rounds, 1)
 }
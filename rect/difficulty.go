@@ -0,0 +1,155 @@
+package rect
+
+import (
+	"context"
+	"crossmatcher/lin"
+	"fmt"
+	"math"
+	"math/big"
+)
+
+// Weights of the measures in the difficulty score. A guess weighs as much as four rounds, the branching adds
+// at most as much as a guess.
+const (
+	roundWeight       = 1.0
+	unaidedLineWeight = 0.5
+	guessWeight       = 4.0
+	backtrackWeight   = 2.0
+	branchingWeight   = 4.0
+)
+
+// Lowest scores of the difficulty labels medium, hard and expert. They are calibrated such that the crosswords
+// of MakeRandomCrossword split into easy and medium ones, harder crosswords need the further mutations of
+// MakeRandomCrosswordOptions.
+const (
+	mediumScore = 6.0
+	hardScore   = 10.0
+	expertScore = 15.0
+)
+
+// Difficulty rates how hard it is to solve a crossword from a constraint.
+type Difficulty struct {
+	Score float64 `json:"score"`
+	Label string  `json:"label"`
+	// Rounds is the number of propagation rounds of the line logic.
+	Rounds int `json:"rounds"`
+	// UnaidedLines is the number of line solves that decided cells before any crossing line narrowed one of its cells.
+	UnaidedLines int `json:"unaidedLines"`
+	// Guesses and Backtracks are the work of the backtracking search after the line logic got stuck.
+	Guesses    int `json:"guesses"`
+	Backtracks int `json:"backtracks"`
+	// Branching is the mean share of the information per cell that a rule leaves open, between 0 and 1.
+	Branching float64 `json:"branching"`
+}
+
+func (d Difficulty) String() string {
+	return fmt.Sprintf("%s (score %.1f)", d.Label, d.Score)
+}
+
+// RateDifficulty rates the difficulty of solving the crossword from the constraint.
+// Fails if the constraint has no unique solution.
+func (c Crossword) RateDifficulty(constraint Candidate) (Difficulty, bool) {
+	difficulty, ok, _ := c.RateDifficultyContext(context.Background(), constraint)
+	return difficulty, ok
+}
+
+// RateDifficultyContext does the same as RateDifficulty, but stops when the context is done.
+// It then fails with the context error.
+func (c Crossword) RateDifficultyContext(ctx context.Context, constraint Candidate) (Difficulty, bool, error) {
+	solutionNum := 0
+	stats, _, err := c.search(ctx, MakeDomain(constraint, c.Alphabet), func(Domain) bool {
+		solutionNum++
+		return solutionNum < 2
+	})
+	if err != nil {
+		return Difficulty{}, false, err
+	}
+	if solutionNum != 1 {
+		return Difficulty{}, false, nil
+	}
+
+	difficulty := Difficulty{Guesses: stats.Guesses, Backtracks: stats.Backtracks, Branching: c.branching()}
+	difficulty.Rounds, difficulty.UnaidedLines = unaidedLines(c.TraceLinearReductions(constraint))
+	difficulty.Score = roundWeight*float64(difficulty.Rounds) +
+		unaidedLineWeight*float64(difficulty.UnaidedLines) +
+		guessWeight*float64(difficulty.Guesses) +
+		backtrackWeight*float64(difficulty.Backtracks) +
+		branchingWeight*difficulty.Branching
+	difficulty.Label = difficultyLabel(difficulty.Score, difficulty.Guesses > 0)
+	return difficulty, true, nil
+}
+
+// difficultyLabel names the range of the score. Puzzles that need guessing are at least hard.
+func difficultyLabel(score float64, guessed bool) string {
+	switch {
+	case score >= expertScore:
+		return "expert"
+	case score >= hardScore || guessed:
+		return "hard"
+	case score >= mediumScore:
+		return "medium"
+	default:
+		return "easy"
+	}
+}
+
+// unaidedLines returns the number of rounds of a trace and the number of its steps on lines
+// that no earlier step of a crossing line had narrowed.
+func unaidedLines(trace Trace) (int, int) {
+	rounds, unaided := 0, 0
+	aided := make(map[line]bool)
+	for _, step := range trace.Steps {
+		rounds = step.Round
+		if !aided[line{step.Vertical, step.Index}] {
+			unaided++
+		}
+		for _, change := range step.Changes {
+			if step.Vertical {
+				aided[line{false, change.Row}] = true
+			} else {
+				aided[line{true, change.Col}] = true
+			}
+		}
+	}
+	return rounds, unaided
+}
+
+// branching returns the mean over all lines of the bits needed to pick a matching filling of the line,
+// relative to the bits of an unrestricted filling.
+func (c Crossword) branching() float64 {
+	bitsPerCell := math.Log2(float64(c.Alphabet.Len()))
+	if bitsPerCell == 0 {
+		return 0
+	}
+	height, width := len(c.Horizontal), len(c.Vertical)
+	sum, lines := 0.0, 0
+	measure := func(rule string, length int) {
+		if length == 0 {
+			return
+		}
+		count := lin.MakeCrossword(rule, c.Alphabet).CountMatches(lin.MakeCandidateEmpty(c.Alphabet, length))
+		sum += log2(count) / (bitsPerCell * float64(length))
+		lines++
+	}
+	for _, rule := range c.Horizontal {
+		measure(rule, width)
+	}
+	for _, rule := range c.Vertical {
+		measure(rule, height)
+	}
+	if lines == 0 {
+		return 0
+	}
+	return sum / float64(lines)
+}
+
+// log2 approximates the binary logarithm of a positive big integer, it returns 0 for smaller ones.
+func log2(x *big.Int) float64 {
+	if x.Sign() <= 0 {
+		return 0
+	}
+	mantissa := new(big.Float)
+	exponent := new(big.Float).SetInt(x).MantExp(mantissa)
+	m, _ := mantissa.Float64()
+	return math.Log2(m) + float64(exponent)
+}
@@ -0,0 +1,103 @@
+package rect
+
+import (
+	"context"
+	"crossmatcher/collection"
+	"testing"
+)
+
+func TestCrossword_RateDifficulty(t *testing.T) {
+	alphabet := collection.MakeAlphabet("ab")
+	crossword := MakeCrossword(alphabet, []string{"ab|ba|bb", "a*"}, []string{"bb|ba|aa", "b*|a*"})
+	difficulty, ok := crossword.RateDifficulty(MakeCandidateEmpty(alphabet, 2, 2))
+	if !ok {
+		t.Fatalf("RateDifficulty fails on a unique crossword")
+	}
+	if difficulty.Guesses != 0 || difficulty.Backtracks != 0 || difficulty.Rounds == 0 || difficulty.UnaidedLines == 0 {
+		t.Errorf("RateDifficulty has incorrect measures: %+v", difficulty)
+	}
+	if difficulty.Branching <= 0 || difficulty.Branching >= 1 {
+		t.Errorf("RateDifficulty has an incorrect branching: %+v", difficulty)
+	}
+	if difficulty.Label != "easy" {
+		t.Errorf("RateDifficulty has an incorrect label: %+v", difficulty)
+	}
+
+	crossword = MakeCrossword(alphabet, []string{"ab|ba", "ab|ba"}, []string{"ab|ba", "ab|ba"})
+	if difficulty, ok := crossword.RateDifficulty(MakeCandidateEmpty(alphabet, 2, 2)); ok {
+		t.Errorf("RateDifficulty rates a crossword with two solutions: %+v", difficulty)
+	}
+	if _, ok := crossword.RateDifficulty(MakeCandidate([]string{"a.", ".."}, '.')); !ok {
+		t.Errorf("RateDifficulty fails on a constraint with a unique solution")
+	}
+}
+
+func TestDifficultyLabel(t *testing.T) {
+	tests := []struct {
+		score   float64
+		guessed bool
+		label   string
+	}{
+		{0, false, "easy"},
+		{mediumScore, false, "medium"},
+		{mediumScore, true, "hard"},
+		{hardScore, false, "hard"},
+		{expertScore, true, "expert"},
+	}
+	for _, test := range tests {
+		if label := difficultyLabel(test.score, test.guessed); label != test.label {
+			t.Errorf("difficultyLabel(%v, %v) = %s, expected %s", test.score, test.guessed, label, test.label)
+		}
+	}
+}
+
+func TestMakeRandomCrosswordRated(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	crossword, difficulty := MakeRandomCrosswordRated(alphabet, 3, 4)
+	expected, ok := crossword.RateDifficulty(MakeCandidateEmpty(alphabet, 3, 4))
+	if !ok || difficulty != expected {
+		t.Errorf("MakeRandomCrosswordRated has an incorrect rating %+v of %v", difficulty, crossword)
+	}
+}
+
+func TestModel_Rate(t *testing.T) {
+	m := NewModel([]string{"bb|ba|aa", "b*|a*"}, []string{"ab|ba|bb", "a*"}, "ab", []string{"..", ".."})
+	if _, ok := m.Difficulty(); ok {
+		t.Errorf("Difficulty of an unrated model succeeds")
+	}
+	m.Rate()
+	expected, ok := m.Difficulty()
+	if !ok {
+		t.Fatalf("Rate fails on a unique crossword")
+	}
+
+	// the rating belongs to the puzzle, so the entries do not change it
+	solved := NewModel([]string{"bb|ba|aa", "b*|a*"}, []string{"ab|ba|bb", "a*"}, "ab", []string{"ba", "aa"})
+	solved.Rate()
+	if difficulty, _ := solved.Difficulty(); difficulty != expected {
+		t.Errorf("Rate depends on the entries: %+v and %+v", difficulty, expected)
+	}
+
+	kept := NewModel([]string{"bb|ba|aa", "b*|a*"}, []string{"ab|ba|bb", "a*"}, "ab", []string{"b.", ".."})
+	kept.keepDifficulty(m)
+	if difficulty, ok := kept.Difficulty(); !ok || difficulty != expected {
+		t.Errorf("keepDifficulty loses the rating of the same crossword: %+v", difficulty)
+	}
+	other := NewModel([]string{"bb|ba|aa", "b*|a*"}, []string{"ab|ba", "a*"}, "ab", []string{"..", ".."})
+	other.keepDifficulty(m)
+	if _, ok := other.Difficulty(); ok {
+		t.Errorf("keepDifficulty keeps the rating of another crossword")
+	}
+}
+
+func TestDifficultyCalibration(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	labels := make(map[string]int)
+	for seed := range int64(12) {
+		generation, _ := Generate(context.Background(), alphabet, 4, 4, seed)
+		labels[generation.Difficulty.Label]++
+	}
+	if labels["easy"] == 0 || labels["medium"] == 0 || labels["hard"]+labels["expert"] > 0 {
+		t.Errorf("The labels do not split the crosswords of the default generator into easy and medium: %v", labels)
+	}
+}
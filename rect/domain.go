@@ -0,0 +1,158 @@
+package rect
+
+import (
+	"crossmatcher/collection"
+	"crossmatcher/lin"
+)
+
+// Domain holds the set of still possible alphabet numbers (the pencil marks) for each cell.
+type Domain struct {
+	Sets     [][]collection.Set
+	Alphabet collection.Alphabet
+}
+
+// MakeDomain makes a domain from a candidate.
+// Fixed characters are kept, wildcards may become any character of the fill alphabet.
+func MakeDomain(candidate Candidate, fill collection.Alphabet) Domain {
+	alphabet := candidate.Alphabet.Merge(fill)
+	sets := make([][]collection.Set, len(candidate.Content))
+	for i := range candidate.Content {
+		row, _ := candidate.GetRow(i)
+		rowDomain := lin.MakeDomain(row, fill)
+		sets[i] = translateSets(rowDomain.Sets, rowDomain.Alphabet, alphabet)
+	}
+	return Domain{sets, alphabet}
+}
+
+// translateSets maps sets of numbers from one alphabet to another alphabet containing all of its characters.
+func translateSets(sets []collection.Set, from, to collection.Alphabet) []collection.Set {
+	ret := make([]collection.Set, len(sets))
+	for i, set := range sets {
+		ret[i] = collection.MakeSet()
+		for _, num := range set.Numbers() {
+			char, _ := from.Char(num)
+			newNum, _ := to.Number(char)
+			ret[i] = ret[i].Insert(newNum)
+		}
+	}
+	return ret
+}
+
+// Candidate returns the candidate of all decided cells. All other cells become wildcards.
+func (d Domain) Candidate() Candidate {
+	content := make(Content, len(d.Sets))
+	for i := range d.Sets {
+		row, _ := d.GetRow(i)
+		content[i] = row.Candidate().Content
+	}
+	return Candidate{content, d.Alphabet}
+}
+
+// CountOpen returns the number of cells that are not decided yet.
+func (d Domain) CountOpen() int {
+	count := 0
+	for i := range d.Sets {
+		row, _ := d.GetRow(i)
+		count += row.CountOpen()
+	}
+	return count
+}
+
+// Marks returns the possible characters of each cell ordered by their alphabet numbers.
+func (d Domain) Marks() [][]string {
+	marks := make([][]string, len(d.Sets))
+	for i := range d.Sets {
+		row, _ := d.GetRow(i)
+		marks[i] = row.Marks()
+	}
+	return marks
+}
+
+// Copy creates an exact copy of the domain.
+func (d Domain) Copy() Domain {
+	sets := make([][]collection.Set, len(d.Sets))
+	for i, row := range d.Sets {
+		sets[i] = make([]collection.Set, len(row))
+		for j, set := range row {
+			sets[i][j] = set.Copy()
+		}
+	}
+	return Domain{sets, d.Alphabet.Copy()}
+}
+
+// Equal checks, whether both domains allow the same numbers in each cell.
+func (d Domain) Equal(other Domain) bool {
+	if len(d.Sets) != len(other.Sets) {
+		return false
+	}
+	for i := range d.Sets {
+		row, _ := d.GetRow(i)
+		otherRow, _ := other.GetRow(i)
+		if !row.Equal(otherRow) {
+			return false
+		}
+	}
+	return true
+}
+
+// GetRow restricts a domain to the given row (which leaves a linear domain over the same alphabet).
+func (d Domain) GetRow(rowNumber int) (lin.Domain, bool) {
+	if len(d.Sets) <= rowNumber {
+		return lin.Domain{}, false
+	}
+	return lin.Domain{Sets: d.Sets[rowNumber], Alphabet: d.Alphabet}, true
+}
+
+// UpdateRow replaces the sets of a row by the sets of a linear domain over the same alphabet.
+func (d Domain) UpdateRow(rowInsert lin.Domain, rowNumber int) (Domain, bool) {
+	if len(d.Sets) <= rowNumber {
+		return d.Copy(), false
+	}
+	if len(d.Sets[rowNumber]) != rowInsert.Len() {
+		return d.Copy(), false
+	}
+	ret := d.Copy()
+	for colNumber, set := range rowInsert.Sets {
+		ret.Sets[rowNumber][colNumber] = set.Copy()
+	}
+	return ret, true
+}
+
+// GetCol restricts a domain to the given column (which leaves a linear domain over the same alphabet).
+func (d Domain) GetCol(colNumber int) (lin.Domain, bool) {
+	sets := make([]collection.Set, len(d.Sets))
+	for i, row := range d.Sets {
+		if len(row) <= colNumber {
+			return lin.Domain{}, false
+		}
+		sets[i] = row[colNumber]
+	}
+	return lin.Domain{Sets: sets, Alphabet: d.Alphabet}, true
+}
+
+// UpdateCol replaces the sets of a column by the sets of a linear domain over the same alphabet.
+func (d Domain) UpdateCol(colInsert lin.Domain, colNumber int) (Domain, bool) {
+	if len(d.Sets) != colInsert.Len() {
+		return d.Copy(), false
+	}
+	ret := d.Copy()
+	for rowNumber, set := range colInsert.Sets {
+		if len(ret.Sets[rowNumber]) <= colNumber {
+			return d.Copy(), false
+		}
+		ret.Sets[rowNumber][colNumber] = set.Copy()
+	}
+	return ret, true
+}
+
+// Union returns a domain that allows all numbers allowed by d or by other in each cell.
+// Both domains must have the same size and alphabet.
+func (d Domain) Union(other Domain) Domain {
+	ret := d.Copy()
+	for i, row := range ret.Sets {
+		for j := range row {
+			row[j] = row[j].Union(other.Sets[i][j])
+		}
+	}
+	return ret
+}
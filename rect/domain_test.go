@@ -0,0 +1,73 @@
+package rect
+
+import (
+	"crossmatcher/collection"
+	"slices"
+	"testing"
+)
+
+func TestDomain_MakeDomain(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	domain := MakeDomain(MakeCandidate([]string{"a.", ".d"}, '.'), alphabet)
+	expected := [][]string{{"a", "abc"}, {"abc", "d"}}
+	for i, row := range domain.Marks() {
+		for j := range row {
+			// the order of the marks follows the numbers of the merged alphabet
+			if sortedMarks(row[j]) != expected[i][j] {
+				t.Errorf("MakeDomain is incorrect at (%d,%d). Expected %s, got %s", i, j, expected[i][j], row[j])
+			}
+		}
+	}
+	actual := domain.Candidate().String()
+	if actual != "a.\n.d" {
+		t.Errorf("Candidate is incorrect. Expected %s, got %s", "a.\n.d", actual)
+	}
+}
+
+func sortedMarks(marks string) string {
+	chars := []rune(marks)
+	slices.Sort(chars)
+	return string(chars)
+}
+
+func TestDomain_UpdateCol(t *testing.T) {
+	alphabet := collection.MakeAlphabet("ab")
+	domain := MakeDomain(MakeCandidateEmpty(alphabet, 3, 2), alphabet)
+	col, _ := domain.GetCol(1)
+	col.Sets[2] = collection.MakeSet(1)
+	updated, ok := domain.UpdateCol(col, 1)
+	if !ok {
+		t.Errorf("UpdateCol not successful.")
+	}
+	if updated.CountOpen() != 5 {
+		t.Errorf("UpdateCol is incorrect. Expected %d open cells, got %d", 5, updated.CountOpen())
+	}
+	if domain.CountOpen() != 6 {
+		t.Errorf("UpdateCol changed the original domain.")
+	}
+	_, ok = domain.UpdateCol(col, 2)
+	if ok {
+		t.Errorf("UpdateCol accepts colNumber which is too large.")
+	}
+}
+
+func TestCrossword_ReduceDomain(t *testing.T) {
+	horizontal := []string{"a|b", "[ab]", "c"}
+	vertical := []string{"(a|b)[ab]c"}
+	alphabet := collection.MakeAlphabet("abc")
+	crossword := MakeCrossword(alphabet, horizontal, vertical)
+	domain, depth := crossword.ReduceDomain(MakeDomain(MakeCandidateEmpty(alphabet, 3, 1), alphabet))
+	if depth == 0 {
+		t.Fatalf("ReduceDomain reports a contradiction on a solvable crossword.")
+	}
+	expected := [][]string{{"ab"}, {"ab"}, {"c"}}
+	for i, row := range domain.Marks() {
+		if !slices.Equal(row, expected[i]) {
+			t.Errorf("ReduceDomain is incorrect in row %d. Expected %v, got %v", i, expected[i], row)
+		}
+	}
+	_, depth = crossword.ReduceDomain(MakeDomain(MakeCandidate([]string{".", ".", "a"}, '.'), alphabet))
+	if depth != 0 {
+		t.Errorf("ReduceDomain does not report a contradiction.")
+	}
+}
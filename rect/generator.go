@@ -0,0 +1,229 @@
+package rect
+
+import (
+	"context"
+	"crossmatcher/collection"
+	"fmt"
+	"math/rand"
+	"time"
+)
+
+// GeneratorOptions configures MakeRandomCrosswordOptions.
+type GeneratorOptions struct {
+	// The difficulty score of the crossword lies in [MinScore, MaxScore). A MaxScore of 0 sets no upper bound.
+	MinScore float64
+	MaxScore float64
+	// Budget bounds the time spent on mutating and restarting, MaxSteps the number of tried rule transformations
+	// over all attempts. Unlike the budget, the step bound does not depend on the machine. Without both only
+	// a single attempt is made.
+	Budget   time.Duration
+	MaxSteps int
+	// Weights are the relative frequencies of the rule transformations, missing ones are never drawn.
+	// Nil uses DefaultTransformationWeights.
+	Weights map[TransformationType]float64
+	// Uniqueness decides how the mutated crosswords are checked for a unique solution.
+	Uniqueness UniquenessCheck
+	// Workers is the number of goroutines solving the lines of the LineLogic check, which pays off on large grids.
+	// Below 2 the lines are solved sequentially.
+	Workers int
+}
+
+// UniquenessCheck is the way the generator checks that a mutated crossword keeps its unique solution.
+type UniquenessCheck int
+
+const (
+	// LineLogic keeps only crosswords that line logic solves, the default.
+	LineLogic UniquenessCheck = iota
+	// CompleteSolver checks with the backtracking search, so that the crosswords may need guessing.
+	// The search of each check stops with the budget, the mutation is then rejected.
+	CompleteSolver
+)
+
+// Generation is the result of a seeded generator run. Generating again with the same seed, alphabet, size and
+// options reproduces the crossword, unless the run was stopped by a context or a time budget.
+type Generation struct {
+	Crossword Crossword
+	// Solution is the grid the rules were built from, the only solution of the crossword.
+	Solution   Candidate
+	Seed       int64
+	Difficulty Difficulty
+	Stats      GenerationStats
+}
+
+// GenerationStats describes the work done by a generator run.
+type GenerationStats struct {
+	Attempts int
+	// Steps counts the tried rule transformations, Changes the ones that kept the solution unique.
+	Steps    int
+	Changes  int
+	Duration time.Duration
+}
+
+func (stats *GenerationStats) addStep(changed bool) {
+	stats.Steps++
+	if changed {
+		stats.Changes++
+	}
+}
+
+// Limits of the automatic tuning of the block sizes, shorter blocks tend to give harder crosswords.
+const (
+	tiltStep = 0.5
+	maxTilt  = 2.0
+)
+
+// minStepFactor and maxStepFactor times the number of rules bound the mutation steps of a single attempt.
+// The lower bound matches the steps of MakeRandomCrossword.
+const (
+	minStepFactor = 5
+	maxStepFactor = 20
+)
+
+// DifficultyBand returns the lowest and highest score of a difficulty label, the highest score of "expert" is 0.
+// Fails on an unknown label.
+func DifficultyBand(label string) (float64, float64, bool) {
+	switch label {
+	case "easy":
+		return 0, mediumScore, true
+	case "medium":
+		return mediumScore, hardScore, true
+	case "hard":
+		return hardScore, expertScore, true
+	case "expert":
+		return expertScore, 0, true
+	default:
+		return 0, 0, false
+	}
+}
+
+// MakeRandomCrosswordOptions makes a random crossword with a unique solution whose difficulty lies in the band
+// of the options. An attempt keeps mutating the rules while the crossword is too easy. If it gets too hard or
+// stops changing, the generator restarts with block sizes tuned towards the band until the budget runs out.
+// Fails if no attempt hit the band, it then returns the crossword closest to the band.
+// Invalid options are reported by an error instead, see GenerateOptions.
+func MakeRandomCrosswordOptions(alphabet collection.Alphabet, height, width int, options GeneratorOptions) (Crossword, Difficulty, bool, error) {
+	generation, ok, err := GenerateOptions(alphabet, height, width, options, rand.Int63())
+	return generation.Crossword, generation.Difficulty, ok, err
+}
+
+// GenerateOptions does the same as MakeRandomCrosswordOptions, but draws all random choices from a generator
+// seeded with seed. It returns an error without generating if the weights belong to unknown transformations,
+// are negative or all zero.
+func GenerateOptions(alphabet collection.Alphabet, height, width int, options GeneratorOptions, seed int64) (Generation, bool, error) {
+	if options.Weights == nil {
+		options.Weights = DefaultTransformationWeights()
+	}
+	if err := checkTransformationWeights(options.Weights); err != nil {
+		return Generation{}, false, fmt.Errorf("invalid transformation weights: %w", err)
+	}
+	start := time.Now()
+	ctx := context.Background()
+	if options.Budget > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.Budget)
+		defer cancel()
+	}
+	rng := rand.New(rand.NewSource(seed))
+
+	var best Generation
+	bestDistance := -1.0
+	stats := GenerationStats{}
+	tilt := 0.0
+	for {
+		generation := makeCrosswordAttempt(ctx, alphabet, height, width, tilt, options, rng, &stats)
+		generation.Seed = seed
+		distance := options.distance(generation.Difficulty.Score)
+		if bestDistance < 0 || distance < bestDistance {
+			best, bestDistance = generation, distance
+		}
+		if options.inBand(generation.Difficulty.Score) || options.exhausted(stats) || ctx.Err() != nil {
+			stats.Duration = time.Since(start)
+			best.Stats = stats
+			return best, options.inBand(best.Difficulty.Score), nil
+		}
+		if generation.Difficulty.Score < options.MinScore {
+			tilt = max(tilt-tiltStep, -maxTilt)
+		} else {
+			tilt = min(tilt+tiltStep, maxTilt)
+		}
+	}
+}
+
+// makeCrosswordAttempt mutates the rules of a random crossword in rounds of one step per rule
+// until its difficulty reaches the band after at least minStepFactor rounds, exceeds it or the rules stop changing.
+// It adds its work to stats.
+func makeCrosswordAttempt(ctx context.Context, alphabet collection.Alphabet, height, width int, tilt float64,
+	options GeneratorOptions, rng *rand.Rand, stats *GenerationStats) Generation {
+	stats.Attempts++
+	tree, solution := makeSeparatedCrosswordTree(alphabet, height, width, tilt, rng)
+	if options.Uniqueness == CompleteSolver {
+		tree.isUnique = func(crossword Crossword) bool {
+			return crossword.hasUniqueSolutionBacktracking(ctx)
+		}
+	} else if options.Workers > 1 {
+		tree.isUnique = func(crossword Crossword) bool {
+			return crossword.hasUniqueSolutionParallel(options.Workers)
+		}
+	}
+	empty := MakeCandidateEmpty(alphabet, height, width)
+	generation := Generation{Solution: solution}
+	for round := 0; ; round++ {
+		crossword := tree.finalSeparationTransformations(rng).ToCrossword()
+		// rating a crossword that needs guessing searches, so the budget may stop it
+		difficulty, _, err := crossword.RateDifficultyContext(ctx, empty)
+		if err != nil {
+			if round == 0 {
+				generation.Crossword = crossword
+			}
+			return generation
+		}
+		if options.MaxScore > 0 && difficulty.Score >= options.MaxScore {
+			// too hard, the previous round may still have been in the band
+			if round == 0 || !options.inBand(generation.Difficulty.Score) {
+				generation.Crossword, generation.Difficulty = crossword, difficulty
+			}
+			return generation
+		}
+		generation.Crossword, generation.Difficulty = crossword, difficulty
+		if difficulty.Score >= options.MinScore && round >= minStepFactor || round == maxStepFactor ||
+			options.outOfSteps(*stats) || ctx.Err() != nil {
+			return generation
+		}
+		changes := stats.Changes
+		for range height + width {
+			if options.outOfSteps(*stats) || ctx.Err() != nil {
+				break
+			}
+			var changed bool
+			tree, changed = tree.transformSingleRule(alphabet, options.Weights, rng)
+			stats.addStep(changed)
+		}
+		if stats.Changes == changes {
+			return generation
+		}
+	}
+}
+
+// exhausted reports whether no further attempt may be made after the work in stats.
+func (options GeneratorOptions) exhausted(stats GenerationStats) bool {
+	return options.outOfSteps(stats) || options.Budget == 0 && options.MaxSteps == 0
+}
+
+func (options GeneratorOptions) outOfSteps(stats GenerationStats) bool {
+	return options.MaxSteps > 0 && stats.Steps >= options.MaxSteps
+}
+
+func (options GeneratorOptions) inBand(score float64) bool {
+	return score >= options.MinScore && (options.MaxScore <= 0 || score < options.MaxScore)
+}
+
+// distance returns how far the score lies outside the band of the options.
+func (options GeneratorOptions) distance(score float64) float64 {
+	if score < options.MinScore {
+		return options.MinScore - score
+	}
+	if options.MaxScore > 0 && score >= options.MaxScore {
+		return score - options.MaxScore
+	}
+	return 0
+}
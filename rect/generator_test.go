@@ -0,0 +1,126 @@
+package rect
+
+import (
+	"context"
+	"crossmatcher/collection"
+	"crossmatcher/lin"
+	"testing"
+	"time"
+)
+
+func TestMakeRandomCrosswordOptions(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	for _, label := range []string{"easy", "medium"} {
+		minScore, maxScore, ok := DifficultyBand(label)
+		if !ok {
+			t.Fatalf("DifficultyBand does not know %s", label)
+		}
+		options := GeneratorOptions{MinScore: minScore, MaxScore: maxScore, MaxSteps: 2000}
+		generation, ok, _ := GenerateOptions(alphabet, 4, 4, options, 1)
+		crossword, difficulty := generation.Crossword, generation.Difficulty
+		if !ok || difficulty.Label != label {
+			t.Errorf("MakeRandomCrosswordOptions misses the band %s: %+v", label, difficulty)
+		}
+		if !crossword.hasUniqueSolution() {
+			t.Errorf("MakeRandomCrosswordOptions has no unique solution: %v", crossword)
+		}
+		if expected, _ := crossword.RateDifficulty(MakeCandidateEmpty(alphabet, 4, 4)); expected.Score != difficulty.Score {
+			t.Errorf("MakeRandomCrosswordOptions has an incorrect rating %+v, expected %+v", difficulty, expected)
+		}
+	}
+
+	// an unreachable band returns the closest crossword once the budget runs out
+	start := time.Now()
+	options := GeneratorOptions{MinScore: 1000, Budget: 200 * time.Millisecond}
+	if _, difficulty, ok, _ := MakeRandomCrosswordOptions(alphabet, 3, 3, options); ok || difficulty.Label == "" {
+		t.Errorf("MakeRandomCrosswordOptions hits an unreachable band: %+v", difficulty)
+	}
+	if elapsed := time.Since(start); elapsed > 2*time.Second {
+		t.Errorf("MakeRandomCrosswordOptions exceeds its budget: %v", elapsed)
+	}
+	options = GeneratorOptions{MinScore: 1000, MaxSteps: 100}
+	if generation, ok, _ := GenerateOptions(alphabet, 3, 3, options, 1); ok || generation.Stats.Steps != 100 || generation.Stats.Attempts < 2 {
+		t.Errorf("GenerateOptions does not use up its step bound: %+v", generation.Stats)
+	}
+
+	if _, _, ok := DifficultyBand("trivial"); ok {
+		t.Errorf("DifficultyBand knows an unknown label")
+	}
+}
+
+func TestCrosswordTree_TryRuleChangeCompleteSolver(t *testing.T) {
+	alphabet := collection.MakeAlphabet("ab")
+	// unique, but line logic decides no cell
+	horizontal := []string{"bbb|bba|aaa", "bba|aaa|abb", "bba|aaa|bab"}
+	vertical := []string{"bab|aba|aab", "aaa|abb|bab", "abb|aaa|bba"}
+	crossword := MakeCrossword(alphabet, horizontal, vertical)
+	if crossword.hasUniqueSolution() || !crossword.hasUniqueSolutionBacktracking(context.Background()) {
+		t.Fatalf("%v is no unique crossword that needs guessing", crossword)
+	}
+	solution, _ := crossword.SolveBacktracking(MakeCandidateEmpty(alphabet, 3, 3))
+	firstRow, _ := solution.GetRow(0)
+
+	tree := CrosswordTree{Alphabet: alphabet}
+	for _, rule := range append([]string{firstRow.String()}, horizontal[1:]...) {
+		node, _ := lin.ParseRegexNode(rule)
+		tree.Horizontal = append(tree.Horizontal, node)
+	}
+	for _, rule := range vertical {
+		node, _ := lin.ParseRegexNode(rule)
+		tree.Vertical = append(tree.Vertical, node)
+	}
+	broader, _ := lin.ParseRegexNode(horizontal[0])
+
+	lineLogic := tree.DeepCopy()
+	lineLogic.tryRuleChange(&lineLogic.Horizontal[0], broader)
+	if actual := lineLogic.Horizontal[0].String(); actual != firstRow.String() {
+		t.Errorf("tryRuleChange keeps a change that line logic can not solve: %s", actual)
+	}
+	complete := tree.DeepCopy()
+	complete.isUnique = func(crossword Crossword) bool {
+		return crossword.hasUniqueSolutionBacktracking(context.Background())
+	}
+	complete.tryRuleChange(&complete.Horizontal[0], broader)
+	if actual := complete.Horizontal[0].String(); actual != horizontal[0] {
+		t.Errorf("tryRuleChange reverts a change that keeps the solution unique: %s", actual)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cancelled := tree.DeepCopy()
+	cancelled.isUnique = func(crossword Crossword) bool {
+		return crossword.hasUniqueSolutionBacktracking(ctx)
+	}
+	cancelled.tryRuleChange(&cancelled.Horizontal[0], broader)
+	if actual := cancelled.Horizontal[0].String(); actual != firstRow.String() {
+		t.Errorf("tryRuleChange keeps a change whose check was cancelled: %s", actual)
+	}
+}
+
+func TestGenerateOptions_CompleteSolver(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	for _, uniqueness := range []UniquenessCheck{CompleteSolver, LineLogic} {
+		options := GeneratorOptions{MinScore: 1000, Uniqueness: uniqueness}
+		for seed := range int64(3) {
+			generation, _, _ := GenerateOptions(alphabet, 3, 3, options, seed)
+			crossword := generation.Crossword
+			if crossword.CountSolutions(MakeCandidateEmpty(alphabet, 3, 3), 0) != 1 || !crossword.CheckSolution(generation.Solution) {
+				t.Errorf("GenerateOptions loses the unique solution %s of %v", generation.Solution.String(), crossword)
+			}
+			if uniqueness == LineLogic && !crossword.hasUniqueSolution() {
+				t.Errorf("GenerateOptions makes a crossword that line logic can not solve: %v", crossword)
+			}
+		}
+	}
+}
+
+func TestGenerateOptions_Workers(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	options := GeneratorOptions{MinScore: 1000, MaxSteps: 200}
+	sequential, _, _ := GenerateOptions(alphabet, 5, 5, options, 3)
+	options.Workers = 4
+	parallel, _, _ := GenerateOptions(alphabet, 5, 5, options, 3)
+	if !sameGeneration(sequential, parallel) {
+		t.Errorf("GenerateOptions with workers differs from the sequential check: %v and %v", sequential.Crossword, parallel.Crossword)
+	}
+}
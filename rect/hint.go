@@ -0,0 +1,47 @@
+package rect
+
+import "fmt"
+
+// Hint is the next deduction from the entries of a model, forced by the rule of a row or column.
+// If Violated is set, the entries already contradict the rule and no cell is given.
+type Hint struct {
+	Vertical bool
+	Index    int
+	Rule     string
+	Row      int
+	Col      int
+	Char     string
+	Reason   string
+	Violated bool
+}
+
+// Hint finds the first cell that line logic decides from the current entries.
+// If the entries contradict a rule, the hint names the violated rule instead.
+// Fails if line logic decides no further cell.
+func (m *Model) Hint() (Hint, bool) {
+	domain := MakeDomain(m.candidate, m.crossword.Alphabet)
+	solvers := m.crossword.lineSolvers(domain)
+	for _, l := range m.crossword.allLines() {
+		if _, solutionNum := solvers[l].solve(domain.getLine(l)); solutionNum == 0 {
+			rule := m.crossword.lineRule(l)
+			return Hint{Vertical: l.vertical, Index: l.index, Rule: rule, Violated: true,
+				Reason: fmt.Sprintf("the entries of %s do not match %s", l, rule)}, true
+		}
+	}
+
+	trace := m.crossword.TraceLinearReductions(m.candidate)
+	if trace.Contradiction {
+		step := trace.Steps[len(trace.Steps)-1]
+		return Hint{Vertical: step.Vertical, Index: step.Index, Rule: step.Rule, Violated: true,
+			Reason: "the entries lead to a contradiction, " + step.Reason}, true
+	}
+	for _, step := range trace.Steps {
+		for _, change := range step.Changes {
+			if len([]rune(change.After)) == 1 {
+				return Hint{Vertical: step.Vertical, Index: step.Index, Rule: step.Rule,
+					Row: change.Row, Col: change.Col, Char: change.After, Reason: step.Reason}, true
+			}
+		}
+	}
+	return Hint{}, false
+}
@@ -0,0 +1,28 @@
+package rect
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestModel_Hint(t *testing.T) {
+	m := NewModel([]string{"bb|ba|aa", "b*|a*"}, []string{"ab|ba|bb", "a*"}, "ab", []string{"..", ".."})
+	hint, ok := m.Hint()
+	if !ok || hint.Violated {
+		t.Fatalf("Hint finds no deduction: %+v", hint)
+	}
+	if hint.Vertical || hint.Index != 1 || hint.Rule != "a*" || hint.Row != 1 || hint.Col != 0 || hint.Char != "a" {
+		t.Errorf("Hint is incorrect: %+v", hint)
+	}
+
+	m = NewModel([]string{"bb|ba|aa", "b*|a*"}, []string{"ab|ba|bb", "a*"}, "ab", []string{"..", "b."})
+	hint, ok = m.Hint()
+	if !ok || !hint.Violated || hint.Rule != "a*" || !strings.Contains(hint.Reason, "row 2") {
+		t.Errorf("Hint does not report the violated rule: %+v", hint)
+	}
+
+	m = NewModel([]string{"(a|b)*", "(a|b)*"}, []string{"(a|b)*", "(a|b)*"}, "ab", []string{"..", ".."})
+	if hint, ok = m.Hint(); ok {
+		t.Errorf("Hint finds a deduction without one: %+v", hint)
+	}
+}
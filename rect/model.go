@@ -1,13 +1,23 @@
 package rect
 
 import (
+	"context"
 	"crossmatcher/collection"
+	"runtime"
+	"slices"
 	"strings"
+	"time"
 )
 
+// searchTimeout bounds the backtracking search of Solve, so that the GUI never hangs on large grids.
+const searchTimeout = 10 * time.Second
+
 type Model struct {
 	crossword Crossword
 	candidate Candidate
+	// difficulty is the rating of the puzzle from an empty grid, if rated is set
+	difficulty Difficulty
+	rated      bool
 }
 
 func NewModel(vRules, hRules []string, alphabetString string, candidate []string) *Model {
@@ -24,7 +34,8 @@ func NewModelRandom(alphabetString string, height, width int) *Model {
 	m := &Model{}
 	alphabet := collection.MakeAlphabet(alphabetString, '.')
 
-	m.crossword = MakeRandomCrossword(alphabet, height, width)
+	m.crossword, m.difficulty = MakeRandomCrosswordRated(alphabet, height, width)
+	m.rated = m.difficulty.Label != ""
 
 	candidate := make([]string, height)
 	for i := range height {
@@ -35,8 +46,41 @@ func NewModelRandom(alphabetString string, height, width int) *Model {
 	return m
 }
 
+// NewModelRandomBand does the same as NewModelRandom, but generates a crossword of the difficulty label.
+// The generator tries for at most searchTimeout and then keeps the crossword closest to the label.
+// Unknown labels generate a crossword of any difficulty. If guessing is allowed, the crossword only needs
+// a unique solution instead of one found by line logic.
+func NewModelRandomBand(alphabetString string, height, width int, label string, allowGuessing bool) *Model {
+	minScore, maxScore, ok := DifficultyBand(label)
+	if !ok && !allowGuessing {
+		return NewModelRandom(alphabetString, height, width)
+	}
+	m := &Model{}
+	alphabet := collection.MakeAlphabet(alphabetString, '.')
+
+	options := GeneratorOptions{MinScore: minScore, MaxScore: maxScore, Budget: searchTimeout, Workers: runtime.NumCPU()}
+	if allowGuessing {
+		options.Uniqueness = CompleteSolver
+	}
+	// the default weights are valid
+	m.crossword, m.difficulty, _, _ = MakeRandomCrosswordOptions(alphabet, height, width, options)
+	// the generator leaves the difficulty unrated if the budget stopped the rating
+	m.rated = m.difficulty.Label != ""
+
+	candidate := make([]string, height)
+	for i := range height {
+		candidate[i] = strings.Repeat(".", width)
+	}
+	m.candidate = MakeCandidate(candidate, '.')
+
+	return m
+}
+
+// Solve returns all cells decided by the linear reductions.
+// If the crossword has a unique solution, the backtracking search completes it unless it exceeds searchTimeout.
+// Marks shows what is known about the cells left undecided.
 func (m *Model) Solve() []string {
-	candidate, count := m.crossword.SolveLinearReductions(m.candidate)
+	domain, count := m.crossword.ReduceDomain(MakeDomain(m.candidate, m.crossword.Alphabet))
 
 	width := len(m.crossword.Vertical)
 	height := len(m.crossword.Horizontal)
@@ -50,20 +94,63 @@ func (m *Model) Solve() []string {
 		return ret
 	}
 
+	ctx, cancel := context.WithTimeout(context.Background(), searchTimeout)
+	defer cancel()
+	solution, count, _, err := m.crossword.solveBacktracking(ctx, domain, 2)
+	if err == nil && count == 1 {
+		domain = solution
+	}
+
+	candidate := domain.Candidate()
 	ret := make([]string, height)
 	for i := 0; i < height; i++ {
 		row, _ := candidate.GetRow(i)
 		ret[i] = row.String()
 	}
 
-	candidate, count = m.crossword.SolveBruteforce(candidate)
+	return ret
+}
 
-	if count == 1 {
+// Marks returns the characters that are still possible in each cell after the linear reductions.
+// On a contradiction, all cells are empty.
+func (m *Model) Marks() [][]string {
+	domain, count := m.crossword.ReduceDomain(MakeDomain(m.candidate, m.crossword.Alphabet))
+
+	width := len(m.crossword.Vertical)
+	height := len(m.crossword.Horizontal)
+
+	if count == 0 {
+		ret := make([][]string, height)
 		for i := 0; i < height; i++ {
-			row, _ := candidate.GetRow(i)
-			ret[i] = row.String()
+			ret[i] = make([]string, width)
 		}
+		return ret
 	}
 
-	return ret
+	return domain.Marks()
+}
+
+// Rate rates the difficulty of solving the crossword from an empty grid and keeps it for Difficulty.
+// The rating fails if the crossword has no unique solution or the rating exceeds searchTimeout.
+func (m *Model) Rate() {
+	ctx, cancel := context.WithTimeout(context.Background(), searchTimeout)
+	defer cancel()
+	empty := MakeCandidateEmpty(m.crossword.Alphabet, len(m.crossword.Horizontal), len(m.crossword.Vertical))
+	m.difficulty, m.rated, _ = m.crossword.RateDifficultyContext(ctx, empty)
+}
+
+// Difficulty returns the rating of the puzzle kept by Rate or by the generator.
+// Fails if the puzzle is not rated.
+func (m *Model) Difficulty() (Difficulty, bool) {
+	return m.difficulty, m.rated
+}
+
+// keepDifficulty takes over the rating of the other model if both have the same crossword.
+func (m *Model) keepDifficulty(other *Model) {
+	if other == nil || !other.rated || m.crossword.Alphabet.String() != other.crossword.Alphabet.String() ||
+		!slices.Equal(m.crossword.Horizontal, other.crossword.Horizontal) ||
+		!slices.Equal(m.crossword.Vertical, other.crossword.Vertical) {
+		return
+	}
+	m.difficulty, m.rated = other.difficulty, true
 }
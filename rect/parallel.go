@@ -0,0 +1,89 @@
+package rect
+
+import (
+	"crossmatcher/lin"
+	"sync"
+)
+
+// lineResult is the solved domain of a single line.
+type lineResult struct {
+	solved      lin.Domain
+	solutionNum int
+}
+
+// SolveLinearReductionsParallel does the same as SolveLinearReductions, but solves the lines with the given
+// number of goroutines. The result is identical to the sequential mode, only the number of rounds may differ.
+func (c Crossword) SolveLinearReductionsParallel(constraint Candidate, workers int) (Candidate, int) {
+	domain, stats, ok := c.PropagateParallel(MakeDomain(constraint, c.Alphabet), workers)
+	if !ok {
+		return Candidate{}, 0
+	}
+	return domain.Candidate(), max(stats.Rounds, 1)
+}
+
+// PropagateParallel narrows the sets of all cells like Propagate, but alternates between solving all queued rows
+// and all queued columns. Lines of the same direction do not share cells, so each round solves its lines
+// concurrently on a pool of the given number of workers and merges the results in the order of the lines.
+// Fails on a contradiction.
+func (c Crossword) PropagateParallel(domain Domain, workers int) (Domain, PropagationStats, bool) {
+	next := domain.Copy()
+	solvers := c.lineSolvers(next)
+	stats := PropagationStats{}
+	workers = max(workers, 1)
+
+	pending := make(map[line]bool)
+	for _, l := range c.allLines() {
+		pending[l] = true
+	}
+	vertical := false
+	for len(pending) > 0 {
+		var batch []line
+		for _, l := range c.allLines() {
+			if l.vertical == vertical && pending[l] {
+				batch = append(batch, l)
+				delete(pending, l)
+			}
+		}
+		vertical = !vertical
+		if len(batch) == 0 {
+			continue
+		}
+		stats.Rounds++
+		stats.LineSolves += len(batch)
+
+		results := next.solveLines(batch, solvers, workers)
+		for i, l := range batch {
+			if results[i].solutionNum == 0 {
+				return Domain{}, stats, false
+			}
+			for _, crossing := range next.updateLine(l, results[i].solved) {
+				pending[crossing] = true
+			}
+		}
+	}
+	return next, stats, true
+}
+
+// solveLines solves the given lines of the domain concurrently. The results are in the order of the lines.
+func (d Domain) solveLines(lines []line, solvers map[line]lineSolver, workers int) []lineResult {
+	results := make([]lineResult, len(lines))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(workers, len(lines)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				current := d.getLine(lines[i])
+				solved, solutionNum := solvers[lines[i]].solve(current)
+				results[i] = lineResult{solved, solutionNum}
+			}
+		}()
+	}
+	for i := range lines {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
@@ -0,0 +1,36 @@
+package rect
+
+import (
+	"crossmatcher/collection"
+	"testing"
+)
+
+func TestCrossword_PropagateParallel(t *testing.T) {
+	alphabet01 := collection.MakeAlphabet("01")
+	alphabetAbc := collection.MakeAlphabet("abc")
+	crosswords := []Crossword{
+		MakeCrossword(alphabet01,
+			[]string{"0*1{3}0*", "((01)|(10))*", "(00.)*", "0*1{4}0*", "(01)*(10)*", "0*1{3}0*"},
+			[]string{"(0.)*", "((01)|(10))*", "1*01*", "(.1)*", "(01)*(11)*", "(.00)*"}),
+		MakeCrossword(alphabetAbc, []string{"a.*", "[bc]+", ".*c"}, []string{"a|b|c.*", ".b.", "..."}),
+		MakeCrossword(alphabetAbc, []string{"(ab|c)+", ".*", "[ac]*"}, []string{".*", "(a|bc)*.", "a?.*"}),
+		MakeCrossword(alphabetAbc, []string{"a", "b"}, []string{"bb", "a."}),
+	}
+	for _, crossword := range crosswords {
+		domain := MakeDomain(MakeCandidateEmpty(crossword.Alphabet, len(crossword.Horizontal), len(crossword.Vertical)), crossword.Alphabet)
+		expected, _, expectedOk := crossword.Propagate(domain)
+		for _, workers := range []int{0, 1, 3, 16} {
+			actual, stats, actualOk := crossword.PropagateParallel(domain, workers)
+			if actualOk != expectedOk {
+				t.Errorf("PropagateParallel on %s with %d workers reports success %t, expected %t", crossword, workers, actualOk, expectedOk)
+				continue
+			}
+			if actualOk && !actual.Equal(expected) {
+				t.Errorf("PropagateParallel on %s with %d workers differs from Propagate. Expected %v, got %v", crossword, workers, expected.Marks(), actual.Marks())
+			}
+			if actualOk && stats.LineSolves < len(crossword.Horizontal)+len(crossword.Vertical) {
+				t.Errorf("PropagateParallel on %s did not solve every line, only %d line solves", crossword, stats.LineSolves)
+			}
+		}
+	}
+}
@@ -0,0 +1,155 @@
+package rect
+
+import (
+	"context"
+	"crossmatcher/lin"
+)
+
+// PropagationStats describes the work done while propagating a domain.
+type PropagationStats struct {
+	LineSolves int
+	Rounds     int
+}
+
+// line identifies a single row or column of a crossword.
+type line struct {
+	vertical bool
+	index    int
+}
+
+// lineSolver solves a single row or column, reusing the automaton of its rule.
+type lineSolver struct {
+	crossword lin.Crossword
+	automaton lin.Automaton
+	compiled  bool
+}
+
+func makeLineSolver(crossword lin.Crossword, domain Domain) lineSolver {
+	automaton, ok := lin.CompileAutomaton(crossword.Rule, domain.Alphabet)
+	return lineSolver{crossword, automaton, ok}
+}
+
+func (s lineSolver) solve(domain lin.Domain) (lin.Domain, int) {
+	if s.compiled {
+		return s.automaton.SolveDomain(domain)
+	}
+	return s.crossword.SolveDomain(domain)
+}
+
+// lineSolvers makes the solvers for all rows followed by all columns.
+func (c Crossword) lineSolvers(domain Domain) map[line]lineSolver {
+	solvers := make(map[line]lineSolver)
+	for rowNumber := range c.Horizontal {
+		rowCrossword, _ := c.GetRow(rowNumber)
+		solvers[line{false, rowNumber}] = makeLineSolver(rowCrossword, domain)
+	}
+	for colNumber := range c.Vertical {
+		colCrossword, _ := c.GetCol(colNumber)
+		solvers[line{true, colNumber}] = makeLineSolver(colCrossword, domain)
+	}
+	return solvers
+}
+
+// Propagate narrows the sets of all cells until every row and column is consistent with its rule.
+// Starting with all rows and columns, a line is only solved again after one of its cells changed.
+// Each round solves the lines that were queued by the previous round.
+// Fails on a contradiction.
+func (c Crossword) Propagate(domain Domain) (Domain, PropagationStats, bool) {
+	next := domain.Copy()
+	stats := PropagationStats{}
+	ok, _ := c.propagate(context.Background(), next, c.lineSolvers(next), c.allLines(), &stats, nil)
+	if !ok {
+		return Domain{}, stats, false
+	}
+	return next, stats, true
+}
+
+// allLines returns all rows followed by all columns.
+func (c Crossword) allLines() []line {
+	var lines []line
+	for rowNumber := range c.Horizontal {
+		lines = append(lines, line{false, rowNumber})
+	}
+	for colNumber := range c.Vertical {
+		lines = append(lines, line{true, colNumber})
+	}
+	return lines
+}
+
+// lineObserver is told about each line solve of a propagation that narrowed a cell or found a contradiction,
+// with the round, the sets of the line before and after and the number of its fillings (0 on a contradiction).
+type lineObserver func(round int, l line, before lin.Domain, after lin.Domain, solutionNum int)
+
+// propagate narrows the domain in place, starting with the given lines, and adds its work to stats.
+// Fails on a contradiction. If the context is done, it stops with the context error and leaves
+// the domain narrowed as far as it got. A non-nil observer is told about every narrowing step.
+func (c Crossword) propagate(ctx context.Context, domain Domain, solvers map[line]lineSolver, queue []line, stats *PropagationStats, observe lineObserver) (bool, error) {
+	queued := make(map[line]bool)
+	for _, l := range queue {
+		queued[l] = true
+	}
+
+	for round := 1; len(queue) > 0; round++ {
+		stats.Rounds++
+		var nextQueue []line
+		for _, l := range queue {
+			if err := ctx.Err(); err != nil {
+				return false, err
+			}
+			queued[l] = false
+			before := domain.getLine(l)
+			if observe != nil {
+				// updateLine replaces the sets of the domain in place
+				before = before.Copy()
+			}
+			solved, solutionNum := solvers[l].solve(before)
+			stats.LineSolves++
+			if solutionNum == 0 {
+				if observe != nil {
+					observe(round, l, before, before, 0)
+				}
+				return false, nil
+			}
+			changed := domain.updateLine(l, solved)
+			if observe != nil && len(changed) > 0 {
+				observe(round, l, before, solved, solutionNum)
+			}
+			for _, crossing := range changed {
+				if !queued[crossing] {
+					queued[crossing] = true
+					nextQueue = append(nextQueue, crossing)
+				}
+			}
+		}
+		queue = nextQueue
+	}
+	return true, nil
+}
+
+// getLine restricts a domain to a row or column.
+func (d Domain) getLine(l line) lin.Domain {
+	if l.vertical {
+		current, _ := d.GetCol(l.index)
+		return current
+	}
+	current, _ := d.GetRow(l.index)
+	return current
+}
+
+// updateLine replaces the changed sets of a line in place and returns the crossing lines of these cells.
+func (d Domain) updateLine(l line, solved lin.Domain) []line {
+	current := d.getLine(l)
+	var changed []line
+	for i, set := range solved.Sets {
+		if set.Equal(current.Sets[i]) {
+			continue
+		}
+		if l.vertical {
+			d.Sets[i][l.index] = set
+		} else {
+			d.Sets[l.index][i] = set
+		}
+		changed = append(changed, line{!l.vertical, i})
+	}
+	return changed
+}
@@ -0,0 +1,39 @@
+package rect
+
+import (
+	"crossmatcher/collection"
+	"testing"
+)
+
+func TestCrossword_Propagate(t *testing.T) {
+	horizontal := []string{"0*1{3}0*", "((01)|(10))*", "(00.)*", "0*1{4}0*", "(01)*(10)*", "0*1{3}0*"}
+	vertical := []string{"(0.)*", "((01)|(10))*", "1*01*", "(.1)*", "(01)*(11)*", "(.00)*"}
+	alphabet := collection.MakeAlphabet("01")
+	crossword := MakeCrossword(alphabet, horizontal, vertical)
+	domain := MakeDomain(MakeCandidateEmpty(alphabet, 6, 6), alphabet)
+	solution, stats, ok := crossword.Propagate(domain)
+	if !ok {
+		t.Fatalf("Propagate reports a contradiction on a solvable crossword.")
+	}
+	expected := "011100\n100110\n001000\n011110\n011010\n001110"
+	if solution.Candidate().String() != expected {
+		t.Errorf("Propagate did not find the solution. Expected %s, got %s", expected, solution.Candidate().String())
+	}
+	if stats.LineSolves < 12 {
+		t.Errorf("Propagate did not solve every line at least once, only %d line solves", stats.LineSolves)
+	}
+	if stats.LineSolves >= 12*stats.Rounds {
+		t.Errorf("Propagate solved all lines in every round: %d line solves in %d rounds", stats.LineSolves, stats.Rounds)
+	}
+	if domain.CountOpen() != 36 {
+		t.Errorf("Propagate changed the original domain.")
+	}
+
+	horizontal = []string{"a", "b"}
+	vertical = []string{"bb", "a."}
+	crossword = MakeCrossword(collection.MakeAlphabet("ab"), horizontal, vertical)
+	_, _, ok = crossword.Propagate(MakeDomain(MakeCandidateEmpty(crossword.Alphabet, 2, 2), crossword.Alphabet))
+	if ok {
+		t.Errorf("Propagate does not report a contradiction.")
+	}
+}
@@ -0,0 +1,148 @@
+package rect
+
+import (
+	"crossmatcher/collection"
+	"crossmatcher/lin"
+	"crossmatcher/sat"
+)
+
+// Encoding is a CNF encoding of a crossword together with a constraint.
+// Every cell has one variable for each of its possible characters, exactly one of them is true.
+// Every rule is encoded by the states of its automaton, unrolled over the length of its line.
+type Encoding struct {
+	CNF      sat.CNF
+	Alphabet collection.Alphabet
+	cells    [][][]choice
+}
+
+// choice is the variable stating that a cell holds the character with the given number
+// or that the automaton of a line is in the state with the given number.
+type choice struct {
+	num      int
+	variable int
+}
+
+// EncodeCNF encodes the solutions of a crossword satisfying the constraint as a CNF.
+// Fails if a rule can not be compiled into an automaton.
+func (c Crossword) EncodeCNF(constraint Candidate) (Encoding, bool) {
+	domain := MakeDomain(constraint, c.Alphabet)
+	encoding := Encoding{Alphabet: domain.Alphabet}
+
+	encoding.cells = make([][][]choice, len(domain.Sets))
+	for i, row := range domain.Sets {
+		encoding.cells[i] = make([][]choice, len(row))
+		for j, set := range row {
+			var variables []int
+			for _, num := range set.Numbers() {
+				variable := encoding.CNF.NewVar()
+				encoding.cells[i][j] = append(encoding.cells[i][j], choice{num, variable})
+				variables = append(variables, variable)
+			}
+			encoding.CNF.AddClause(variables...)
+			for k := range variables {
+				for l := k + 1; l < len(variables); l++ {
+					encoding.CNF.AddClause(-variables[k], -variables[l])
+				}
+			}
+		}
+	}
+
+	for _, l := range c.allLines() {
+		var rule string
+		var cells [][]choice
+		if l.vertical {
+			rule = c.Vertical[l.index]
+			for i := range encoding.cells {
+				cells = append(cells, encoding.cells[i][l.index])
+			}
+		} else {
+			rule = c.Horizontal[l.index]
+			cells = encoding.cells[l.index]
+		}
+		automaton, ok := lin.CompileAutomaton(rule, domain.Alphabet)
+		if !ok {
+			return Encoding{}, false
+		}
+		encoding.addLine(automaton, cells)
+	}
+	return encoding, true
+}
+
+// addLine adds the clauses forcing the cells of a line to be accepted by the automaton.
+// A state variable of position i implies the state variable reached by the character of cell i.
+// Only states that can still lead to acceptance get variables.
+func (e *Encoding) addLine(automaton lin.Automaton, cells [][]choice) {
+	length := len(cells)
+	allowed := make([]collection.Set, length)
+	for i, cell := range cells {
+		allowed[i] = collection.MakeSet()
+		for _, choice := range cell {
+			allowed[i] = allowed[i].Insert(choice.num)
+		}
+	}
+	live := automaton.Live(allowed)
+	if !live[0][automaton.Start] {
+		e.CNF.AddClause()
+		return
+	}
+
+	// the variables of the states of position i, in the order of their creation
+	states := []choice{{automaton.Start, e.CNF.NewVar()}}
+	e.CNF.AddClause(states[0].variable)
+	for i := range length {
+		var nextStates []choice
+		nextVars := make(map[int]int)
+		for _, state := range states {
+			for _, cell := range cells[i] {
+				next := automaton.Next[state.num][cell.num]
+				if next == -1 || !live[i+1][next] {
+					e.CNF.AddClause(-state.variable, -cell.variable)
+					continue
+				}
+				nextVar, ok := nextVars[next]
+				if !ok {
+					nextVar = e.CNF.NewVar()
+					nextVars[next] = nextVar
+					nextStates = append(nextStates, choice{next, nextVar})
+				}
+				e.CNF.AddClause(-state.variable, -cell.variable, nextVar)
+			}
+		}
+		states = nextStates
+	}
+}
+
+// Decode reads the candidate chosen by a model of the CNF.
+// Fails if a cell has no chosen character.
+func (e Encoding) Decode(model []bool) (Candidate, bool) {
+	content := make(Content, len(e.cells))
+	for i, row := range e.cells {
+		content[i] = make(lin.Content, len(row))
+		for j, cell := range row {
+			content[i][j] = -1
+			for _, choice := range cell {
+				if choice.variable < len(model) && model[choice.variable] {
+					content[i][j] = choice.num
+				}
+			}
+			if content[i][j] == -1 {
+				return Candidate{}, false
+			}
+		}
+	}
+	return Candidate{content, e.Alphabet}, true
+}
+
+// SolveSAT solves the crossword with the built-in CDCL solver.
+// Fails if there is no solution or a rule can not be compiled into an automaton.
+func (c Crossword) SolveSAT(constraint Candidate) (Candidate, bool) {
+	encoding, ok := c.EncodeCNF(constraint)
+	if !ok {
+		return Candidate{}, false
+	}
+	model, ok := encoding.CNF.Solve()
+	if !ok {
+		return Candidate{}, false
+	}
+	return encoding.Decode(model)
+}
@@ -0,0 +1,81 @@
+package rect
+
+import (
+	"bytes"
+	"crossmatcher/collection"
+	"crossmatcher/sat"
+	"testing"
+)
+
+func TestCrossword_SolveSAT(t *testing.T) {
+	horizontal := []string{"0*1{3}0*", "((01)|(10))*", "(00.)*", "0*1{4}0*", "(01)*(10)*", "0*1{3}0*"}
+	vertical := []string{"(0.)*", "((01)|(10))*", "1*01*", "(.1)*", "(01)*(11)*", "(.00)*"}
+	alphabet := collection.MakeAlphabet("01")
+	crossword := MakeCrossword(alphabet, horizontal, vertical)
+	solution, ok := crossword.SolveSAT(MakeCandidateEmpty(alphabet, 6, 6))
+	if !ok {
+		t.Fatalf("SolveSAT did not find a solution.")
+	}
+	expected := "011100\n100110\n001000\n011110\n011010\n001110"
+	if solution.String() != expected {
+		t.Errorf("SolveSAT did not find the solution. Expected %s, got %s", expected, solution.String())
+	}
+
+	_, ok = crossword.SolveSAT(MakeCandidate([]string{"1.....", "......", "......", "......", "......", "......"}, '.'))
+	if ok {
+		t.Errorf("SolveSAT finds a solution contradicting the constraint.")
+	}
+}
+
+func TestCrossword_EncodeCNF(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	crosswords := []Crossword{
+		MakeCrossword(alphabet, []string{"a.*", "[bc]+", ".*c"}, []string{"a|b|c.*", ".b.", "..."}),
+		MakeCrossword(alphabet, []string{"(ab|c)+", ".*", "[ac]*"}, []string{".*", "(a|bc)*.", "a?.*"}),
+		MakeCrossword(alphabet, []string{"b.*", "a*", "c*"}, []string{".*", ".*", ".*"}),
+	}
+	for _, crossword := range crosswords {
+		constraint := MakeCandidateEmpty(alphabet, 3, 3)
+		_, expected := crossword.SolveBruteforce(constraint)
+		encoding, ok := crossword.EncodeCNF(constraint)
+		if !ok {
+			t.Fatalf("EncodeCNF fails on %s", crossword)
+		}
+
+		// count the models by blocking the cell variables of each found solution
+		cnf := encoding.CNF
+		actual := 0
+		for {
+			model, ok := cnf.Solve()
+			if !ok {
+				break
+			}
+			solution, ok := encoding.Decode(model)
+			if !ok || !crossword.CheckSolution(solution) {
+				t.Fatalf("Decode returns a non-solution of %s", crossword)
+			}
+			actual++
+			var blocking []int
+			for _, row := range encoding.cells {
+				for _, cell := range row {
+					for _, choice := range cell {
+						if model[choice.variable] {
+							blocking = append(blocking, -choice.variable)
+						}
+					}
+				}
+			}
+			cnf.AddClause(blocking...)
+		}
+		if actual != expected {
+			t.Errorf("EncodeCNF of %s has %d solutions, expected %d", crossword, actual, expected)
+		}
+
+		var buffer bytes.Buffer
+		_ = encoding.CNF.WriteDIMACS(&buffer)
+		read, err := sat.ReadDIMACS(&buffer)
+		if err != nil || read.NumVars != encoding.CNF.NumVars || len(read.Clauses) != len(encoding.CNF.Clauses) {
+			t.Errorf("DIMACS export of %s can not be read back: %v", crossword, err)
+		}
+	}
+}
@@ -0,0 +1,140 @@
+package rect
+
+import (
+	"context"
+	"crossmatcher/collection"
+	"iter"
+)
+
+// SearchStats describes the work done by a backtracking search.
+type SearchStats struct {
+	Propagation PropagationStats
+	Guesses     int
+	Backtracks  int
+}
+
+// SolveBacktracking finds all solutions satisfying the constraint by guessing cells and propagating each guess.
+// It returns the cells shared by all solutions and the number of solutions.
+func (c Crossword) SolveBacktracking(constraint Candidate) (Candidate, int) {
+	candidate, solutionNum, _ := c.SolveBacktrackingContext(context.Background(), constraint)
+	return candidate, solutionNum
+}
+
+// SolveBacktrackingContext does the same as SolveBacktracking, but stops when the context is done.
+// It then returns the context error, the cells decided by the initial propagation
+// and the number of solutions found so far.
+func (c Crossword) SolveBacktrackingContext(ctx context.Context, constraint Candidate) (Candidate, int, error) {
+	domain, solutionNum, _, err := c.solveBacktracking(ctx, MakeDomain(constraint, c.Alphabet), 0)
+	if err != nil {
+		return domain.Candidate(), solutionNum, err
+	}
+	if solutionNum == 0 {
+		return Candidate{}, 0, nil
+	}
+	return domain.Candidate(), solutionNum, nil
+}
+
+// Solutions enumerates all candidates that fill the wildcards given by the constraint and satisfy the crossword.
+// The solutions are found lazily by the backtracking search, so stopping early saves the remaining search.
+func (c Crossword) Solutions(constraint Candidate) iter.Seq[Candidate] {
+	return func(yield func(Candidate) bool) {
+		c.search(context.Background(), MakeDomain(constraint, c.Alphabet), func(solution Domain) bool {
+			return yield(solution.Candidate())
+		})
+	}
+}
+
+// CountSolutions counts the solutions satisfying the constraint, but stops as soon as limit solutions are found.
+// A limit <= 0 counts all solutions. A puzzle is unique exactly if CountSolutions(constraint, 2) == 1.
+func (c Crossword) CountSolutions(constraint Candidate, limit int) int {
+	solutionNum, _ := c.CountSolutionsContext(context.Background(), constraint, limit)
+	return solutionNum
+}
+
+// CountSolutionsContext does the same as CountSolutions, but stops when the context is done.
+// It then returns the number of solutions found so far and the context error.
+func (c Crossword) CountSolutionsContext(ctx context.Context, constraint Candidate, limit int) (int, error) {
+	_, solutionNum, _, err := c.solveBacktracking(ctx, MakeDomain(constraint, c.Alphabet), limit)
+	return solutionNum, err
+}
+
+// solveBacktracking searches at most limit solutions of the domain (all solutions if limit <= 0).
+// It returns the union of the found solutions, their number and the work done.
+// If the context is done, it returns the propagated domain instead of the union together with the context error.
+func (c Crossword) solveBacktracking(ctx context.Context, domain Domain, limit int) (Domain, int, SearchStats, error) {
+	var union Domain
+	solutionNum := 0
+	stats, propagated, err := c.search(ctx, domain, func(solution Domain) bool {
+		if solutionNum == 0 {
+			union = solution.Copy()
+		} else {
+			union = union.Union(solution)
+		}
+		solutionNum++
+		return limit <= 0 || solutionNum < limit
+	})
+	if err != nil {
+		return propagated, solutionNum, stats, err
+	}
+	return union, solutionNum, stats, nil
+}
+
+// search propagates the domain and enumerates all its solutions in depth-first order.
+// Each solution is passed to yield as a fully decided domain, returning false stops the search.
+// It returns the work done, the propagated domain and the context error if the context is done.
+func (c Crossword) search(ctx context.Context, domain Domain, yield func(Domain) bool) (SearchStats, Domain, error) {
+	stats := SearchStats{}
+	next := domain.Copy()
+	solvers := c.lineSolvers(next)
+	ok, err := c.propagate(ctx, next, solvers, c.allLines(), &stats.Propagation, nil)
+	if err != nil {
+		// narrowing is sound, so the partly propagated domain is still valid
+		return stats, next, err
+	}
+	if !ok {
+		return stats, Domain{}, nil
+	}
+	_, err = c.searchPropagated(ctx, next, solvers, &stats, yield)
+	return stats, next, err
+}
+
+// searchPropagated guesses the most constrained cell of a propagated domain and backtracks on contradictions.
+// Returns false if yield stopped the search or the context is done.
+func (c Crossword) searchPropagated(ctx context.Context, domain Domain, solvers map[line]lineSolver, stats *SearchStats, yield func(Domain) bool) (bool, error) {
+	row, col, ok := domain.mostConstrainedCell()
+	if !ok {
+		return yield(domain), nil
+	}
+	for _, num := range domain.Sets[row][col].Numbers() {
+		guess := domain.Copy()
+		guess.Sets[row][col] = collection.MakeSet(num)
+		stats.Guesses++
+		ok, err := c.propagate(ctx, guess, solvers, []line{{false, row}, {true, col}}, &stats.Propagation, nil)
+		if err != nil {
+			return false, err
+		}
+		if !ok {
+			stats.Backtracks++
+			continue
+		}
+		if ok, err := c.searchPropagated(ctx, guess, solvers, stats, yield); !ok {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+// mostConstrainedCell finds an undecided cell with the fewest possible characters.
+// Fails if all cells are decided.
+func (d Domain) mostConstrainedCell() (int, int, bool) {
+	bestRow, bestCol, bestLen := -1, -1, 0
+	for i, row := range d.Sets {
+		for j, set := range row {
+			size := set.Len()
+			if size > 1 && (bestLen == 0 || size < bestLen) {
+				bestRow, bestCol, bestLen = i, j, size
+			}
+		}
+	}
+	return bestRow, bestCol, bestLen > 0
+}
@@ -0,0 +1,115 @@
+package rect
+
+import (
+	"context"
+	"crossmatcher/collection"
+	"testing"
+)
+
+func TestCrossword_SolveBacktracking(t *testing.T) {
+	horizontal := []string{"ab|ba", "ab|ba"}
+	vertical := []string{"ab|ba", "ab|ba"}
+	alphabet := collection.MakeAlphabet("ab")
+	crossword := MakeCrossword(alphabet, horizontal, vertical)
+	solution, count := crossword.SolveBacktracking(MakeCandidateEmpty(alphabet, 2, 2))
+	if count != 2 {
+		t.Errorf("SolveBacktracking did not find the correct number of solutions. Expected %d, got %d", 2, count)
+	}
+	if solution.String() != "..\n.." {
+		t.Errorf("SolveBacktracking is incorrect. Expected %s, got %s", "..\n..", solution.String())
+	}
+	solution, count = crossword.SolveBacktracking(MakeCandidate([]string{"..", ".a"}, '.'))
+	if count != 1 {
+		t.Errorf("SolveBacktracking did not find the correct number of solutions. Expected %d, got %d", 1, count)
+	}
+	if solution.String() != "ab\nba" {
+		t.Errorf("SolveBacktracking is incorrect. Expected %s, got %s", "ab\nba", solution.String())
+	}
+}
+
+func TestCrossword_SolveBacktrackingMatchesBruteforce(t *testing.T) {
+	alphabet := collection.MakeAlphabet("abc")
+	crosswords := []Crossword{
+		MakeCrossword(alphabet, []string{"a.*", "[bc]+", ".*c"}, []string{"a|b|c.*", ".b.", "..."}),
+		MakeCrossword(alphabet, []string{"(ab|c)+", ".*", "[ac]*"}, []string{".*", "(a|bc)*.", "a?.*"}),
+		MakeCrossword(alphabet, []string{"(.)*", "a*b*c*", "c*b*a*"}, []string{"[ab].c", ".*", "a.*|.*c"}),
+	}
+	for _, crossword := range crosswords {
+		constraint := MakeCandidateEmpty(alphabet, 3, 3)
+		_, expected := crossword.SolveBruteforce(constraint)
+		_, actual := crossword.SolveBacktracking(constraint)
+		if actual != expected {
+			t.Errorf("SolveBacktracking on %s did not find the correct number of solutions. Expected %d, got %d", crossword, expected, actual)
+		}
+	}
+}
+
+func TestCrossword_SolveBacktrackingGuesses(t *testing.T) {
+	horizontal := []string{"bbb|bba|aab", "baa|bbb|aab", "bab|aab|abb"}
+	vertical := []string{"abb|aba|bba", "aba|abb|baa", "aaa|abb|bab"}
+	alphabet := collection.MakeAlphabet("ab")
+	crossword := MakeCrossword(alphabet, horizontal, vertical)
+	constraint := MakeCandidateEmpty(alphabet, 3, 3)
+	linear, _ := crossword.SolveLinearReductions(constraint)
+	if linear.CountWildcards() == 0 {
+		t.Fatalf("Test crossword is already solved by linear reductions.")
+	}
+	_, count, stats, _ := crossword.solveBacktracking(context.Background(), MakeDomain(constraint, alphabet), 0)
+	if count != 1 {
+		t.Errorf("solveBacktracking did not find the correct number of solutions. Expected %d, got %d", 1, count)
+	}
+	if stats.Guesses == 0 {
+		t.Errorf("solveBacktracking did not report its guesses.")
+	}
+}
+
+func TestCrossword_Solutions(t *testing.T) {
+	horizontal := []string{"ab|ba|aa", "ab|ba"}
+	vertical := []string{"ab|ba", "a.|b."}
+	alphabet := collection.MakeAlphabet("ab")
+	crossword := MakeCrossword(alphabet, horizontal, vertical)
+	seen := make(map[string]bool)
+	for solution := range crossword.Solutions(MakeCandidateEmpty(alphabet, 2, 2)) {
+		if !crossword.CheckSolution(solution) {
+			t.Errorf("Solutions yields a non-solution %s", solution.String())
+		}
+		seen[solution.String()] = true
+	}
+	if len(seen) != 3 {
+		t.Errorf("Solutions did not find all distinct solutions. Expected %d, got %v", 3, seen)
+	}
+
+	count := 0
+	for range crossword.Solutions(MakeCandidateEmpty(alphabet, 2, 2)) {
+		count++
+		break
+	}
+	if count != 1 {
+		t.Errorf("Solutions does not stop early.")
+	}
+}
+
+func TestCrossword_CountSolutions(t *testing.T) {
+	horizontal := []string{".*", ".*", ".*"}
+	vertical := []string{".*", ".*", ".*"}
+	alphabet := collection.MakeAlphabet("abcd")
+	crossword := MakeCrossword(alphabet, horizontal, vertical)
+	constraint := MakeCandidateEmpty(alphabet, 3, 3)
+	count := crossword.CountSolutions(constraint, 2)
+	if count != 2 {
+		t.Errorf("CountSolutions did not stop at the limit. Expected %d, got %d", 2, count)
+	}
+	count = crossword.CountSolutions(MakeCandidate([]string{"abc", "dab", "cd."}, '.'), 0)
+	if count != 4 {
+		t.Errorf("CountSolutions did not find the correct number of solutions. Expected %d, got %d", 4, count)
+	}
+
+	horizontal = []string{"bbb|bba|aab", "baa|bbb|aab", "bab|aab|abb"}
+	vertical = []string{"abb|aba|bba", "aba|abb|baa", "aaa|abb|bab"}
+	alphabet = collection.MakeAlphabet("ab")
+	crossword = MakeCrossword(alphabet, horizontal, vertical)
+	count = crossword.CountSolutions(MakeCandidateEmpty(alphabet, 3, 3), 2)
+	if count != 1 {
+		t.Errorf("CountSolutions does not recognize a unique puzzle. Expected %d, got %d", 1, count)
+	}
+}
@@ -0,0 +1,128 @@
+package rect
+
+import (
+	"context"
+	"crossmatcher/lin"
+	"fmt"
+	"strings"
+)
+
+// Trace records the deductions of a solve by line logic. It can be serialised as JSON and replayed
+// by applying the changes of its steps in order.
+type Trace struct {
+	Steps         []TraceStep `json:"steps"`
+	Marks         [][]string  `json:"marks"`
+	Solved        bool        `json:"solved"`
+	Contradiction bool        `json:"contradiction"`
+}
+
+// TraceStep is a single application of a row or column rule.
+// Only applications that narrowed a cell or found a contradiction are recorded.
+type TraceStep struct {
+	Round    int          `json:"round"`
+	Vertical bool         `json:"vertical"`
+	Index    int          `json:"index"`
+	Rule     string       `json:"rule"`
+	Changes  []CellChange `json:"changes"`
+	Reason   string       `json:"reason"`
+}
+
+// CellChange holds the possible characters of a cell before and after a step.
+type CellChange struct {
+	Row    int    `json:"row"`
+	Col    int    `json:"col"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// TraceLinearReductions solves the constraint by line logic like SolveLinearReductions and records every deduction.
+// The deductions are observed while propagating, so they follow the order of Propagate.
+func (c Crossword) TraceLinearReductions(constraint Candidate) Trace {
+	domain := MakeDomain(constraint, c.Alphabet)
+	solvers := c.lineSolvers(domain)
+	trace := Trace{}
+
+	ok, _ := c.propagate(context.Background(), domain, solvers, c.allLines(), &PropagationStats{},
+		func(round int, l line, before lin.Domain, after lin.Domain, solutionNum int) {
+			trace.Steps = append(trace.Steps, c.traceStep(round, l, solvers[l], before, after, solutionNum))
+		})
+	if !ok {
+		trace.Contradiction = true
+		trace.Marks = domain.Marks()
+		return trace
+	}
+	trace.Marks = domain.Marks()
+	trace.Solved = domain.CountOpen() == 0
+	return trace
+}
+
+// traceStep describes the narrowing of a line from before to after.
+func (c Crossword) traceStep(round int, l line, solver lineSolver, before lin.Domain, after lin.Domain, solutionNum int) TraceStep {
+	step := TraceStep{Round: round, Vertical: l.vertical, Index: l.index, Rule: c.lineRule(l)}
+	beforeMarks, afterMarks := before.Marks(), after.Marks()
+	for i := range beforeMarks {
+		if beforeMarks[i] == afterMarks[i] {
+			continue
+		}
+		change := CellChange{Row: l.index, Col: i, Before: beforeMarks[i], After: afterMarks[i]}
+		if l.vertical {
+			change.Row, change.Col = i, l.index
+		}
+		step.Changes = append(step.Changes, change)
+	}
+	step.Reason = c.traceReason(l, solver, before, after, solutionNum)
+	return step
+}
+
+func (c Crossword) lineRule(l line) string {
+	if l.vertical {
+		return c.Vertical[l.index]
+	}
+	return c.Horizontal[l.index]
+}
+
+func (l line) String() string {
+	if l.vertical {
+		return fmt.Sprintf("column %d", l.index+1)
+	}
+	return fmt.Sprintf("row %d", l.index+1)
+}
+
+// traceReason explains a step by the number of fillings left and, for rules that are alternations,
+// by the alternatives that still fit the line. Alternatives are only checked for rules without backreferences,
+// since a single alternative loses the groups of the others.
+func (c Crossword) traceReason(l line, solver lineSolver, before lin.Domain, after lin.Domain, solutionNum int) string {
+	rule := c.lineRule(l)
+	if solutionNum == 0 {
+		return fmt.Sprintf("no filling of %s with length %d and the known cells matches %s", l, before.Len(), rule)
+	}
+
+	var reason string
+	if solutionNum == 1 {
+		reason = fmt.Sprintf("only %s fits %s", after.Candidate().String(), l)
+	} else {
+		fixed := 0
+		for i, set := range after.Sets {
+			if set.Len() == 1 && before.Sets[i].Len() > 1 {
+				fixed++
+			}
+		}
+		reason = fmt.Sprintf("%d fillings fit %s, they fix %d cells and narrow the others", solutionNum, l, fixed)
+	}
+
+	node, ok := lin.ParseRegexNode(rule)
+	if !ok || !solver.compiled || node.Type != lin.Alternation {
+		return reason
+	}
+	var fitting []string
+	for _, alternative := range node.Children {
+		alternativeRule := alternative.String()
+		if _, count := lin.MakeCrossword(alternativeRule, c.Alphabet).SolveDomain(before); count > 0 {
+			fitting = append(fitting, alternativeRule)
+		}
+	}
+	if len(fitting) < len(node.Children) {
+		reason += fmt.Sprintf(", since only the alternatives %s fit length %d and the known cells", strings.Join(fitting, ", "), before.Len())
+	}
+	return reason
+}
@@ -0,0 +1,55 @@
+package rect
+
+import (
+	"crossmatcher/collection"
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCrossword_TraceLinearReductions(t *testing.T) {
+	alphabet := collection.MakeAlphabet("ab")
+	crossword := MakeCrossword(alphabet, []string{"ab|ba|bb", "a*"}, []string{"bb|ba|aa", "b*|a*"})
+	trace := crossword.TraceLinearReductions(MakeCandidateEmpty(alphabet, 2, 2))
+	if !trace.Solved || trace.Contradiction {
+		t.Fatalf("TraceLinearReductions does not solve the crossword: %+v", trace)
+	}
+	if !reflect.DeepEqual(trace.Marks, [][]string{{"b", "a"}, {"a", "a"}}) {
+		t.Errorf("TraceLinearReductions has incorrect marks: %v", trace.Marks)
+	}
+	first := trace.Steps[0]
+	if first.Round != 1 || first.Vertical || first.Index != 1 || first.Rule != "a*" || len(first.Changes) != 2 {
+		t.Errorf("TraceLinearReductions has an incorrect first step: %+v", first)
+	}
+	if first.Changes[0] != (CellChange{Row: 1, Col: 0, Before: "ab", After: "a"}) {
+		t.Errorf("TraceLinearReductions has an incorrect change: %+v", first.Changes[0])
+	}
+	for _, step := range trace.Steps {
+		if step.Rule == "ab|ba|bb" && !strings.Contains(step.Reason, "only the alternatives") {
+			t.Errorf("TraceLinearReductions does not explain the alternatives: %s", step.Reason)
+		}
+	}
+
+	encoded, err := json.Marshal(trace)
+	if err != nil {
+		t.Fatalf("Trace can not be encoded: %v", err)
+	}
+	var decoded Trace
+	if err := json.Unmarshal(encoded, &decoded); err != nil || !reflect.DeepEqual(decoded, trace) {
+		t.Errorf("Trace does not survive a JSON round trip: %s", encoded)
+	}
+}
+
+func TestCrossword_TraceLinearReductionsContradiction(t *testing.T) {
+	alphabet := collection.MakeAlphabet("ab")
+	crossword := MakeCrossword(alphabet, []string{"a*", "a*"}, []string{"b.", ".."})
+	trace := crossword.TraceLinearReductions(MakeCandidateEmpty(alphabet, 2, 2))
+	if !trace.Contradiction || trace.Solved {
+		t.Fatalf("TraceLinearReductions does not report the contradiction: %+v", trace)
+	}
+	last := trace.Steps[len(trace.Steps)-1]
+	if last.Rule != "b." || !strings.HasPrefix(last.Reason, "no filling") {
+		t.Errorf("TraceLinearReductions has an incorrect last step: %+v", last)
+	}
+}
@@ -2,18 +2,23 @@ package rect
 
 import (
 	"crossmatcher/gui"
+	"crossmatcher/lin"
 	"errors"
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/app"
+	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
 	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/theme"
 	"fyne.io/fyne/v2/widget"
-	"regexp"
 	"slices"
 	"strconv"
 	"strings"
 )
 
+// difficultyPrefix starts the difficulty label and the difficulty section of exported crosswords.
+const difficultyPrefix = "Difficulty: "
+
 type View struct {
 	window        fyne.Window
 	model         *Model
@@ -26,6 +31,9 @@ type View struct {
 	hArrows       *fyne.Container
 	vArrows       *fyne.Container
 	charBoxes     *fyne.Container
+	difficulty    *widget.Label
+	band          *widget.Select
+	allowGuessing *widget.Check
 	content       *fyne.Container
 }
 
@@ -62,8 +70,21 @@ func (v *View) updateView(vRules, hRules []string, alphabet string, candidate []
 	importExportButton := gui.MakeButton("Import/Export", v.onImportExport)
 	updateLengthButton := gui.MakeButton("Reset Crossword and Update Length", v.onUpdateLength)
 	createCrosswordButton := gui.MakeButton("Generate Random Crossword", v.onCreateCrossword)
+	band := "any"
+	if v.band != nil {
+		band = v.band.Selected
+	}
+	v.band = widget.NewSelect([]string{"any", "easy", "medium", "hard", "expert"}, nil)
+	v.band.SetSelected(band)
+	allowGuessing := v.allowGuessing != nil && v.allowGuessing.Checked
+	v.allowGuessing = widget.NewCheck("Allow Guessing", nil)
+	v.allowGuessing.SetChecked(allowGuessing)
 	emptyCandidateButton := gui.MakeButton("Empty Candidate", v.onEmptyCandidate)
 	solveButton := gui.MakeButton("Solve", v.onSolve)
+	hintButton := gui.MakeButton("Hint", v.onHint)
+	marksButton := gui.MakeButton("Pencil Marks", v.onShowMarks)
+	rateButton := gui.MakeButton("Rate Difficulty", v.onRateDifficulty)
+	v.difficulty = widget.NewLabel(difficultyPrefix + "unrated")
 
 	ruleLayer := container.NewVBox(v.vRules,
 		gui.MakeCharBoxSpacer(),
@@ -93,11 +114,17 @@ func (v *View) updateView(vRules, hRules []string, alphabet string, candidate []
 		container.NewHBox(v.fullSpace, v.alphabetEntry),
 		container.NewHBox(v.fullSpace),
 		container.NewHBox(v.fullSpace, updateLengthButton),
-		container.NewHBox(v.fullSpace, createCrosswordButton),
+		container.NewHBox(v.fullSpace, createCrosswordButton, v.band),
+		container.NewHBox(v.fullSpace, v.allowGuessing),
 		container.NewHBox(v.fullSpace),
 		container.NewHBox(v.fullSpace, emptyCandidateButton),
 		container.NewHBox(v.fullSpace),
-		container.NewHBox(v.fullSpace, solveButton))
+		container.NewHBox(v.fullSpace, solveButton),
+		container.NewHBox(v.fullSpace, hintButton),
+		container.NewHBox(v.fullSpace, marksButton),
+		container.NewHBox(v.fullSpace),
+		container.NewHBox(v.fullSpace, rateButton),
+		container.NewHBox(v.fullSpace, v.difficulty))
 
 	v.content = container.NewStack(ruleLayer, arrowLayer, candidateLayer, controlLayer)
 
@@ -119,6 +146,10 @@ func (v *View) onImportExport() {
 		strings.Join(hRules, "\n") + "\n\n" +
 		strings.Join(vRules, "\n") + "\n\n" +
 		strings.Join(candidate, "\n")
+	v.setModel(NewModel(vRules, hRules, alphabet, candidate))
+	if difficulty, ok := v.model.Difficulty(); ok {
+		text += "\n\n" + difficultyPrefix + difficulty.String()
+	}
 
 	textArea.SetText(text)
 	textArea.Resize(fyne.NewSize(300, 400))
@@ -152,6 +183,10 @@ func (v *View) onImport(textbox string) {
 	}
 
 	v.updateView(vRules, hRules, alphabet, candidate)
+	// The optional fifth section with the stored rating is not trusted, the puzzle is rated again once
+	v.model = NewModel(vRules, hRules, alphabet, candidate)
+	v.model.Rate()
+	v.showDifficulty()
 
 	v.window.SetContent(v.content)
 	v.window.Resize(fyne.NewSize(400, 300))
@@ -202,7 +237,7 @@ func (v *View) onCreateCrossword() {
 		return
 	}
 
-	m := NewModelRandom(alphabetString, height, width)
+	m := NewModelRandomBand(alphabetString, height, width, v.band.Selected, v.allowGuessing.Checked)
 
 	vRuleStrings := m.crossword.Vertical
 	hRuleStrings := m.crossword.Horizontal
@@ -212,6 +247,8 @@ func (v *View) onCreateCrossword() {
 	}
 
 	v.updateView(vRuleStrings, hRuleStrings, alphabetString, candidate)
+	v.model = m
+	v.showDifficulty()
 
 	v.window.SetContent(v.content)
 	v.window.Resize(fyne.NewSize(400, 300))
@@ -237,12 +274,162 @@ func (v *View) onSolve() {
 	hRules := readRuleRows(v.hRules)
 	alphabet, _ := gui.GetEntryText(v.alphabetEntry)
 	candidate := GetCandidateChars(v.charBoxes, width, height)
-	v.model = NewModel(vRules, hRules, alphabet, candidate)
+	v.setModel(NewModel(vRules, hRules, alphabet, candidate))
 	candidate = v.model.Solve()
 	AddCandidateChars(v.charBoxes, candidate)
 
 }
 
+// onShowMarks shows the characters that are still possible in each cell after the linear reductions,
+// so that partial knowledge is visible where Solve can not decide a cell.
+func (v *View) onShowMarks() {
+	width := len(v.vRules.Objects)
+	height := len(v.hRules.Objects)
+	vRules := readRuleRows(v.vRules)
+	slices.Reverse(vRules)
+	hRules := readRuleRows(v.hRules)
+	alphabet, _ := gui.GetEntryText(v.alphabetEntry)
+	candidate := GetCandidateChars(v.charBoxes, width, height)
+	v.setModel(NewModel(vRules, hRules, alphabet, candidate))
+
+	marks := v.model.Marks()
+	if len(marks) == 0 || len(marks[0]) == 0 || marks[0][0] == "" {
+		dialog.ShowInformation("Pencil Marks", "The entries contradict the rules.", v.window)
+		return
+	}
+	message := widget.NewLabel(formatMarks(marks))
+	message.TextStyle = fyne.TextStyle{Monospace: true}
+	dialog.ShowCustom("Pencil Marks", "Close", message, v.window)
+}
+
+// formatMarks prints the marks as a grid with columns as wide as their widest cell.
+// Undecided cells are put in braces.
+func formatMarks(marks [][]string) string {
+	cells := make([][]string, len(marks))
+	var widths []int
+	for i, row := range marks {
+		cells[i] = make([]string, len(row))
+		for j, mark := range row {
+			cells[i][j] = mark
+			if len([]rune(mark)) > 1 {
+				cells[i][j] = "{" + mark + "}"
+			}
+			if j == len(widths) {
+				widths = append(widths, 0)
+			}
+			widths[j] = max(widths[j], len([]rune(cells[i][j])))
+		}
+	}
+	lines := make([]string, len(cells))
+	for i, row := range cells {
+		for j, cell := range row {
+			row[j] = cell + strings.Repeat(" ", widths[j]-len([]rune(cell)))
+		}
+		lines[i] = strings.TrimRight(strings.Join(row, " "), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// onRateDifficulty rates the difficulty of solving the crossword from an empty grid, e.g. after editing its rules.
+func (v *View) onRateDifficulty() {
+	width := len(v.vRules.Objects)
+	height := len(v.hRules.Objects)
+	vRules := readRuleRows(v.vRules)
+	slices.Reverse(vRules)
+	hRules := readRuleRows(v.hRules)
+	alphabet, _ := gui.GetEntryText(v.alphabetEntry)
+	candidate := GetCandidateChars(v.charBoxes, width, height)
+	v.model = NewModel(vRules, hRules, alphabet, candidate)
+	v.model.Rate()
+	v.showDifficulty()
+}
+
+// setModel replaces the model by one read from the view, keeping the rating if the crossword is unchanged.
+func (v *View) setModel(m *Model) {
+	m.keepDifficulty(v.model)
+	v.model = m
+}
+
+func (v *View) showDifficulty() {
+	difficulty, ok := v.model.Difficulty()
+	if !ok {
+		v.difficulty.SetText(difficultyPrefix + "unrated")
+		return
+	}
+	v.difficulty.SetText(difficultyPrefix + difficulty.String())
+}
+
+// onHint highlights the next deducible cell and the rule forcing it and offers to fill it in.
+// If the entries contradict a rule, that rule is highlighted instead.
+func (v *View) onHint() {
+	width := len(v.vRules.Objects)
+	height := len(v.hRules.Objects)
+	vRules := readRuleRows(v.vRules)
+	slices.Reverse(vRules)
+	hRules := readRuleRows(v.hRules)
+	alphabet, _ := gui.GetEntryText(v.alphabetEntry)
+	candidate := GetCandidateChars(v.charBoxes, width, height)
+	v.setModel(NewModel(vRules, hRules, alphabet, candidate))
+
+	hint, ok := v.model.Hint()
+	if !ok {
+		v.highlightRule(false, -1)
+		dialog.ShowInformation("Hint", "No further cell follows from the current entries by line logic.", v.window)
+		return
+	}
+	v.highlightRule(hint.Vertical, hint.Index)
+	if hint.Violated {
+		dialog.ShowInformation("Hint", "Rule "+hint.Rule+" is violated: "+hint.Reason+".", v.window)
+		return
+	}
+
+	box, ok := (*getCandidateBox(v.charBoxes, hint.Col, hint.Row, width)).(*widget.Entry)
+	if !ok {
+		return
+	}
+	v.window.Canvas().Focus(box)
+	message := widget.NewLabel("Cell " + strconv.Itoa(hint.Row+1) + "/" + strconv.Itoa(hint.Col+1) +
+		" is " + hint.Char + " by rule " + hint.Rule + ":\n" + hint.Reason + ".")
+	message.Wrapping = fyne.TextWrapWord
+	fill := func(fillButton bool) {
+		if fillButton {
+			box.SetText(hint.Char)
+		}
+	}
+	dialogWindow := dialog.NewCustomConfirm("Hint", "Fill In", "Close", message, fill, v.window)
+	dialogWindow.Resize(fyne.NewSize(400, 200))
+	dialogWindow.Show()
+}
+
+// highlightRule colors the arrow of a row or column in the primary color and all other arrows in the foreground color.
+// An index of -1 removes all highlights.
+func (v *View) highlightRule(vertical bool, index int) {
+	width := len(v.vRules.Objects)
+	for i, row := range v.hArrows.Objects {
+		setArrowHighlight(row, !vertical && i == index)
+	}
+	for i, column := range v.vArrows.Objects {
+		// The vertical rules are reversed
+		setArrowHighlight(column, vertical && width-1-i == index)
+	}
+}
+
+func setArrowHighlight(line fyne.CanvasObject, highlighted bool) {
+	vbox, ok := getSecondVBoxFromRuleLine(line)
+	if !ok {
+		return
+	}
+	arrow, ok := vbox.Objects[0].(*canvas.Text)
+	if !ok {
+		return
+	}
+	arrow.Color = theme.Color(theme.ColorNameForeground)
+	if highlighted {
+		arrow.Color = theme.Color(theme.ColorNamePrimary)
+	}
+	arrow.Refresh()
+}
+
 func getCandidateBox(grid *fyne.Container, row, column, width int) *fyne.CanvasObject {
 	gridRow := grid.Objects[width-1-row+column]
 	rowContainer, ok := gridRow.(*fyne.Container)
@@ -368,10 +555,7 @@ func (v *View) addRuleValidators() {
 				candidate := GetCandidateChars(v.charBoxes, width, height)
 
 				candidateRow := candidate[i]
-				rowRule := "^(" + s + ")$"
-				matched, _ := regexp.MatchString(rowRule, candidateRow)
-
-				if !matched {
+				if !lin.MatchRule(s, candidateRow) {
 					return errors.New("rule does not match")
 				}
 				return nil // Shows checkmark
@@ -393,10 +577,7 @@ func (v *View) addRuleValidators() {
 						candidateColumn += string([]rune(candidateRow)[width-1-i])
 					}
 				}
-				rowRule := "^(" + s + ")$"
-				matched, _ := regexp.MatchString(rowRule, candidateColumn)
-
-				if !matched {
+				if !lin.MatchRule(s, candidateColumn) {
 					return errors.New("rule does not match")
 				}
 				return nil // Shows checkmark
@@ -0,0 +1,119 @@
+package sat
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// CNF is a formula in conjunctive normal form.
+// Variables are numbered from 1, a negative literal is the negation of its variable.
+type CNF struct {
+	NumVars int
+	Clauses [][]int
+}
+
+// NewVar adds a fresh variable and returns its number.
+func (cnf *CNF) NewVar() int {
+	cnf.NumVars++
+	return cnf.NumVars
+}
+
+// AddClause adds the disjunction of the given literals. An empty clause makes the formula unsatisfiable.
+func (cnf *CNF) AddClause(literals ...int) {
+	cnf.Clauses = append(cnf.Clauses, append([]int(nil), literals...))
+}
+
+// WriteDIMACS writes the formula in the DIMACS cnf format.
+func (cnf CNF) WriteDIMACS(w io.Writer) error {
+	buffered := bufio.NewWriter(w)
+	if _, err := fmt.Fprintf(buffered, "p cnf %d %d\n", cnf.NumVars, len(cnf.Clauses)); err != nil {
+		return err
+	}
+	for _, clause := range cnf.Clauses {
+		for _, literal := range clause {
+			buffered.WriteString(strconv.Itoa(literal))
+			buffered.WriteByte(' ')
+		}
+		buffered.WriteString("0\n")
+	}
+	return buffered.Flush()
+}
+
+// ReadDIMACS reads a formula in the DIMACS cnf format.
+func ReadDIMACS(r io.Reader) (CNF, error) {
+	cnf := CNF{}
+	var clause []int
+	headerRead := false
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "c") || strings.HasPrefix(text, "%") {
+			continue
+		}
+		if strings.HasPrefix(text, "p") {
+			var numClauses int
+			if _, err := fmt.Sscanf(text, "p cnf %d %d", &cnf.NumVars, &numClauses); err != nil {
+				return CNF{}, fmt.Errorf("invalid header %q: %w", text, err)
+			}
+			headerRead = true
+			continue
+		}
+		if !headerRead {
+			return CNF{}, errors.New("clause before header")
+		}
+		for _, field := range strings.Fields(text) {
+			literal, err := strconv.Atoi(field)
+			if err != nil {
+				return CNF{}, fmt.Errorf("invalid literal %q: %w", field, err)
+			}
+			if literal == 0 {
+				cnf.AddClause(clause...)
+				clause = clause[:0]
+				continue
+			}
+			if literal > cnf.NumVars || -literal > cnf.NumVars {
+				return CNF{}, fmt.Errorf("literal %d exceeds %d variables", literal, cnf.NumVars)
+			}
+			clause = append(clause, literal)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return CNF{}, err
+	}
+	if len(clause) > 0 {
+		cnf.AddClause(clause...)
+	}
+	return cnf, nil
+}
+
+// Satisfies checks, whether an assignment (indexed by variable number) satisfies all clauses.
+// An assignment missing a variable of the clauses does not satisfy them.
+func (cnf CNF) Satisfies(model []bool) bool {
+	for _, clause := range cnf.Clauses {
+		satisfied := false
+		for _, literal := range clause {
+			if len(model) <= abs(literal) {
+				return false
+			}
+			if (literal > 0) == model[abs(literal)] {
+				satisfied = true
+				break
+			}
+		}
+		if !satisfied {
+			return false
+		}
+	}
+	return true
+}
+
+func abs(x int) int {
+	if x < 0 {
+		return -x
+	}
+	return x
+}
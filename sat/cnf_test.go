@@ -0,0 +1,52 @@
+package sat
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestCNF_WriteDIMACS(t *testing.T) {
+	cnf := CNF{}
+	a, b := cnf.NewVar(), cnf.NewVar()
+	cnf.AddClause(a, -b)
+	cnf.AddClause(b)
+	var buffer bytes.Buffer
+	if err := cnf.WriteDIMACS(&buffer); err != nil {
+		t.Fatalf("WriteDIMACS fails: %v", err)
+	}
+	expected := "p cnf 2 2\n1 -2 0\n2 0\n"
+	if buffer.String() != expected {
+		t.Errorf("WriteDIMACS is incorrect. Expected %q, got %q", expected, buffer.String())
+	}
+}
+
+func TestReadDIMACS(t *testing.T) {
+	text := "c example\np cnf 3 2\n1 -3 0\n2\n3 -1 0\n"
+	cnf, err := ReadDIMACS(strings.NewReader(text))
+	if err != nil {
+		t.Fatalf("ReadDIMACS fails: %v", err)
+	}
+	if cnf.NumVars != 3 || len(cnf.Clauses) != 2 || len(cnf.Clauses[1]) != 3 {
+		t.Errorf("ReadDIMACS is incorrect. Got %v", cnf)
+	}
+	_, err = ReadDIMACS(strings.NewReader("p cnf 1 1\n2 0\n"))
+	if err == nil {
+		t.Errorf("ReadDIMACS accepts a literal exceeding the number of variables.")
+	}
+}
+
+func TestCNF_Satisfies(t *testing.T) {
+	cnf := CNF{}
+	a, b := cnf.NewVar(), cnf.NewVar()
+	cnf.AddClause(a, -b)
+	if !cnf.Satisfies([]bool{false, true, false}) {
+		t.Errorf("Satisfies rejects a satisfying assignment.")
+	}
+	if cnf.Satisfies([]bool{false, false, true}) {
+		t.Errorf("Satisfies accepts a falsifying assignment.")
+	}
+	if cnf.Satisfies([]bool{false, false}) {
+		t.Errorf("Satisfies accepts an assignment missing a variable.")
+	}
+}
@@ -0,0 +1,308 @@
+package sat
+
+// solver is a conflict driven clause learning solver with two watched literals,
+// first unique implication point learning, activity based decisions, phase saving and luby restarts.
+// Literals are stored as 2*(variable-1) for positive and 2*(variable-1)+1 for negative literals.
+type solver struct {
+	numVars   int
+	clauses   [][]int
+	watches   [][]int
+	value     []int8 // per variable: 0 unassigned, 1 true, -1 false
+	level     []int
+	reason    []int // clause index, -1 for decisions
+	phase     []bool
+	activity  []float64
+	increment float64
+	trail     []int
+	trailLim  []int
+	queueHead int
+	seen      []bool
+}
+
+const restartUnit = 64
+
+// Solve searches an assignment satisfying all clauses.
+// The returned model is indexed by variable number, index 0 is unused.
+// Fails if the formula is unsatisfiable.
+func (cnf CNF) Solve() ([]bool, bool) {
+	s := &solver{
+		numVars:   cnf.NumVars,
+		watches:   make([][]int, 2*cnf.NumVars),
+		value:     make([]int8, cnf.NumVars),
+		level:     make([]int, cnf.NumVars),
+		reason:    make([]int, cnf.NumVars),
+		phase:     make([]bool, cnf.NumVars),
+		activity:  make([]float64, cnf.NumVars),
+		increment: 1,
+		seen:      make([]bool, cnf.NumVars),
+	}
+	for _, clause := range cnf.Clauses {
+		if !s.addClause(clause) {
+			return nil, false
+		}
+	}
+	if !s.search() {
+		return nil, false
+	}
+
+	model := make([]bool, cnf.NumVars+1)
+	for v := range cnf.NumVars {
+		model[v+1] = s.value[v] == 1
+	}
+	return model, true
+}
+
+func toLit(literal int) int {
+	if literal > 0 {
+		return 2 * (literal - 1)
+	}
+	return 2*(-literal-1) + 1
+}
+
+func litVar(lit int) int {
+	return lit / 2
+}
+
+// litValue returns 1 if lit is true, -1 if it is false and 0 if it is unassigned.
+func (s *solver) litValue(lit int) int8 {
+	value := s.value[litVar(lit)]
+	if lit%2 == 1 {
+		return -value
+	}
+	return value
+}
+
+// addClause adds an input clause at decision level 0. Fails if the formula became unsatisfiable.
+func (s *solver) addClause(literals []int) bool {
+	var clause []int
+	seen := make(map[int]bool)
+	for _, literal := range literals {
+		lit := toLit(literal)
+		if seen[lit^1] || s.litValue(lit) == 1 {
+			return true
+		}
+		if seen[lit] || s.litValue(lit) == -1 {
+			continue
+		}
+		seen[lit] = true
+		clause = append(clause, lit)
+	}
+	switch len(clause) {
+	case 0:
+		return false
+	case 1:
+		s.assign(clause[0], -1)
+		return s.propagate() == -1
+	default:
+		s.attach(clause)
+		return true
+	}
+}
+
+func (s *solver) attach(clause []int) int {
+	index := len(s.clauses)
+	s.clauses = append(s.clauses, clause)
+	s.watches[clause[0]] = append(s.watches[clause[0]], index)
+	s.watches[clause[1]] = append(s.watches[clause[1]], index)
+	return index
+}
+
+func (s *solver) assign(lit int, reason int) {
+	v := litVar(lit)
+	if lit%2 == 0 {
+		s.value[v] = 1
+	} else {
+		s.value[v] = -1
+	}
+	s.level[v] = len(s.trailLim)
+	s.reason[v] = reason
+	s.trail = append(s.trail, lit)
+}
+
+// propagate assigns all unit literals. It returns the index of a conflicting clause or -1.
+func (s *solver) propagate() int {
+	for s.queueHead < len(s.trail) {
+		falseLit := s.trail[s.queueHead] ^ 1
+		s.queueHead++
+
+		watchers := s.watches[falseLit]
+		kept := watchers[:0]
+		for i, index := range watchers {
+			clause := s.clauses[index]
+			if clause[0] == falseLit {
+				clause[0], clause[1] = clause[1], clause[0]
+			}
+			if s.litValue(clause[0]) == 1 {
+				kept = append(kept, index)
+				continue
+			}
+			moved := false
+			for k := 2; k < len(clause); k++ {
+				if s.litValue(clause[k]) != -1 {
+					clause[1], clause[k] = clause[k], clause[1]
+					s.watches[clause[1]] = append(s.watches[clause[1]], index)
+					moved = true
+					break
+				}
+			}
+			if moved {
+				continue
+			}
+			kept = append(kept, index)
+			if s.litValue(clause[0]) == -1 {
+				kept = append(kept, watchers[i+1:]...)
+				s.watches[falseLit] = kept
+				s.queueHead = len(s.trail)
+				return index
+			}
+			s.assign(clause[0], index)
+		}
+		s.watches[falseLit] = kept
+	}
+	return -1
+}
+
+// analyze derives the first unique implication point clause of a conflict and the level to backtrack to.
+func (s *solver) analyze(conflict int) ([]int, int) {
+	learnt := []int{-1}
+	pending := 0
+	lit := -1
+	index := len(s.trail) - 1
+	currentLevel := len(s.trailLim)
+
+	for {
+		for _, q := range s.clauses[conflict] {
+			if q == lit {
+				continue
+			}
+			v := litVar(q)
+			if s.seen[v] || s.level[v] == 0 {
+				continue
+			}
+			s.seen[v] = true
+			s.bump(v)
+			if s.level[v] == currentLevel {
+				pending++
+			} else {
+				learnt = append(learnt, q)
+			}
+		}
+		for !s.seen[litVar(s.trail[index])] {
+			index--
+		}
+		lit = s.trail[index]
+		index--
+		s.seen[litVar(lit)] = false
+		pending--
+		if pending == 0 {
+			break
+		}
+		conflict = s.reason[litVar(lit)]
+	}
+	learnt[0] = lit ^ 1
+
+	backtrackLevel := 0
+	for i := 1; i < len(learnt); i++ {
+		s.seen[litVar(learnt[i])] = false
+		if s.level[litVar(learnt[i])] > backtrackLevel {
+			backtrackLevel = s.level[litVar(learnt[i])]
+			learnt[1], learnt[i] = learnt[i], learnt[1]
+		}
+	}
+	return learnt, backtrackLevel
+}
+
+func (s *solver) bump(v int) {
+	s.activity[v] += s.increment
+	if s.activity[v] > 1e100 {
+		for i := range s.activity {
+			s.activity[i] *= 1e-100
+		}
+		s.increment *= 1e-100
+	}
+}
+
+func (s *solver) backtrack(level int) {
+	if len(s.trailLim) <= level {
+		return
+	}
+	for i := len(s.trail) - 1; i >= s.trailLim[level]; i-- {
+		v := litVar(s.trail[i])
+		s.phase[v] = s.value[v] == 1
+		s.value[v] = 0
+	}
+	s.trail = s.trail[:s.trailLim[level]]
+	s.trailLim = s.trailLim[:level]
+	s.queueHead = len(s.trail)
+}
+
+// decide picks the unassigned variable with the highest activity. Fails if all variables are assigned.
+func (s *solver) decide() (int, bool) {
+	best := -1
+	for v := range s.numVars {
+		if s.value[v] == 0 && (best == -1 || s.activity[v] > s.activity[best]) {
+			best = v
+		}
+	}
+	if best == -1 {
+		return 0, false
+	}
+	if s.phase[best] {
+		return 2 * best, true
+	}
+	return 2*best + 1, true
+}
+
+func (s *solver) search() bool {
+	if s.propagate() != -1 {
+		return false
+	}
+	conflicts := 0
+	restart := 0
+	for {
+		conflict := s.propagate()
+		if conflict != -1 {
+			if len(s.trailLim) == 0 {
+				return false
+			}
+			conflicts++
+			learnt, backtrackLevel := s.analyze(conflict)
+			s.backtrack(backtrackLevel)
+			if len(learnt) == 1 {
+				s.assign(learnt[0], -1)
+			} else {
+				s.assign(learnt[0], s.attach(learnt))
+			}
+			s.increment /= 0.95
+			continue
+		}
+
+		if conflicts >= luby(restart)*restartUnit {
+			conflicts = 0
+			restart++
+			s.backtrack(0)
+		}
+
+		lit, ok := s.decide()
+		if !ok {
+			return true
+		}
+		s.trailLim = append(s.trailLim, len(s.trail))
+		s.assign(lit, -1)
+	}
+}
+
+// luby returns the i-th element (starting at 0) of the luby sequence 1, 1, 2, 1, 1, 2, 4, ...
+func luby(i int) int {
+	size, exponent := 1, 0
+	for size < i+1 {
+		exponent++
+		size = 2*size + 1
+	}
+	for size-1 != i {
+		size = (size - 1) / 2
+		exponent--
+		i = i % size
+	}
+	return 1 << exponent
+}
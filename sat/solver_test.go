@@ -0,0 +1,107 @@
+package sat
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+)
+
+func TestLuby(t *testing.T) {
+	expected := []int{1, 1, 2, 1, 1, 2, 4, 1, 1, 2, 1, 1, 2, 4, 8}
+	actual := make([]int, len(expected))
+	for i := range actual {
+		actual[i] = luby(i)
+	}
+	if !slices.Equal(actual, expected) {
+		t.Errorf("luby is incorrect. Expected %v, got %v", expected, actual)
+	}
+}
+
+func TestCNF_Solve(t *testing.T) {
+	cnf := CNF{}
+	a, b, c := cnf.NewVar(), cnf.NewVar(), cnf.NewVar()
+	cnf.AddClause(a, b)
+	cnf.AddClause(-a, c)
+	cnf.AddClause(-b, c)
+	cnf.AddClause(-c, -a)
+	model, ok := cnf.Solve()
+	if !ok {
+		t.Fatalf("Solve reports a satisfiable formula as unsatisfiable.")
+	}
+	if !cnf.Satisfies(model) {
+		t.Errorf("Solve returns a model that does not satisfy the formula: %v", model)
+	}
+	cnf.AddClause(-c)
+	_, ok = cnf.Solve()
+	if ok {
+		t.Errorf("Solve reports an unsatisfiable formula as satisfiable.")
+	}
+	cnf = CNF{NumVars: 1}
+	cnf.AddClause()
+	_, ok = cnf.Solve()
+	if ok {
+		t.Errorf("Solve accepts the empty clause.")
+	}
+}
+
+func TestCNF_SolvePigeonhole(t *testing.T) {
+	// 6 pigeons do not fit into 5 holes
+	pigeons, holes := 6, 5
+	cnf := CNF{}
+	vars := make([][]int, pigeons)
+	for p := range pigeons {
+		vars[p] = make([]int, holes)
+		for h := range holes {
+			vars[p][h] = cnf.NewVar()
+		}
+		cnf.AddClause(vars[p]...)
+	}
+	for h := range holes {
+		for p := range pigeons {
+			for q := p + 1; q < pigeons; q++ {
+				cnf.AddClause(-vars[p][h], -vars[q][h])
+			}
+		}
+	}
+	_, ok := cnf.Solve()
+	if ok {
+		t.Errorf("Solve finds a solution to the pigeonhole problem.")
+	}
+}
+
+func TestCNF_SolveRandom(t *testing.T) {
+	rng := rand.New(rand.NewSource(7))
+	for range 200 {
+		cnf := CNF{NumVars: 10}
+		for range 43 {
+			clause := make([]int, 3)
+			for i := range clause {
+				clause[i] = 1 + rng.Intn(cnf.NumVars)
+				if rng.Intn(2) == 0 {
+					clause[i] = -clause[i]
+				}
+			}
+			cnf.AddClause(clause...)
+		}
+
+		expected := false
+		model := make([]bool, cnf.NumVars+1)
+		for bits := range 1 << cnf.NumVars {
+			for v := 1; v <= cnf.NumVars; v++ {
+				model[v] = bits&(1<<(v-1)) != 0
+			}
+			if cnf.Satisfies(model) {
+				expected = true
+				break
+			}
+		}
+
+		model, actual := cnf.Solve()
+		if actual != expected {
+			t.Fatalf("Solve is incorrect. Expected satisfiable %t, got %t", expected, actual)
+		}
+		if actual && !cnf.Satisfies(model) {
+			t.Fatalf("Solve returns a model that does not satisfy the formula.")
+		}
+	}
+}